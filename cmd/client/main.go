@@ -5,14 +5,30 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/SergeySlonimsky/pow/internal/client"
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
 )
 
 func main() {
 	serverURL := fmt.Sprintf("%s:%s", os.Getenv("SERVER_HOST"), os.Getenv("SERVER_PORT"))
 
-	if err := client.Run(context.Background(), serverURL); err != nil {
+	var opts []client.Option
+	if os.Getenv("CLIENT_PROTOCOL_VERSION") == "1" {
+		opts = append(opts, client.WithProtocolVersion(protocol.V1))
+	}
+
+	if quotes := os.Getenv("CLIENT_QUOTES"); quotes != "" {
+		count, err := strconv.Atoi(quotes)
+		if err != nil {
+			log.Fatalf("invalid CLIENT_QUOTES: %q", quotes)
+		}
+
+		opts = append(opts, client.WithQuotes(count))
+	}
+
+	if err := client.Run(context.Background(), serverURL, opts...); err != nil {
 		log.Fatalf("run client: %s", err.Error())
 	}
 }
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// defaultExpiry is the validity period of stamps, used by the classic hashcash tool.
+const defaultExpiry = 28 * 24 * time.Hour
+
+const defaultClockSkew = 5 * time.Minute
+
+var (
+	errResourceMismatch  = errors.New("stamp is minted for another resource")
+	errAlgorithmMismatch = errors.New("stamp uses another hash algorithm")
+	errTooEasy           = errors.New("stamp difficulty is below required bits")
+	errSpent             = errors.New("stamp is already spent")
+)
+
+type checkOptions struct {
+	resource  string
+	bits      int
+	algorithm hashcash.Algorithm
+	expiry    time.Duration
+	skew      time.Duration
+}
+
+// check verifies stamps, read from arguments or in, and records valid ones in double-spend database, when it's set.
+// Every stamp is reported to out on its own line, and errInvalidStamps is returned, when any of them is invalid.
+func check(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	resource := flags.String("r", "", "required resource of stamps, any resource is accepted when empty")
+	bits := flags.Int("b", defaultBits, "minimum difficulty of stamps in leading zero bits")
+	algorithm := flags.String("a", "", "required hash algorithm of stamps, any algorithm is accepted when empty")
+	expiry := flags.Duration("e", defaultExpiry, "validity period of stamps, 0 disables expiration")
+	skew := flags.Duration("skew", defaultClockSkew, "tolerated difference between future stamp date and current time")
+	dbFile := flags.String("db", "", "double-spend database file, stamps are not recorded when empty")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	opts := checkOptions{
+		resource: *resource,
+		bits:     *bits,
+		expiry:   *expiry,
+		skew:     *skew,
+	}
+
+	if *algorithm != "" {
+		alg, err := hashcash.ParseAlgorithm(*algorithm)
+		if err != nil {
+			return fmt.Errorf("flag -a: %w", err)
+		}
+
+		opts.algorithm = alg
+	}
+
+	stamps, err := readStamps(flags.Args(), in)
+	if err != nil {
+		return err
+	}
+
+	var db *spentDB
+	if *dbFile != "" {
+		if db, err = openSpentDB(*dbFile, *expiry); err != nil {
+			return err
+		}
+	}
+
+	invalid := false
+
+	for _, data := range stamps {
+		if err := checkStamp(data, opts, db); err != nil {
+			invalid = true
+
+			fmt.Fprintf(out, "%s: invalid: %s\n", data, err)
+
+			continue
+		}
+
+		fmt.Fprintf(out, "%s: valid\n", data)
+	}
+
+	if db != nil {
+		if err := db.save(); err != nil {
+			return err
+		}
+	}
+
+	if invalid {
+		return errInvalidStamps
+	}
+
+	return nil
+}
+
+func checkStamp(data string, opts checkOptions, db *spentDB) error {
+	stamp, err := hashcash.FromString(data)
+	if err != nil {
+		return err
+	}
+
+	if opts.resource != "" && stamp.GetResource() != opts.resource {
+		return errResourceMismatch
+	}
+
+	if opts.algorithm != "" && stamp.GetAlgorithm() != opts.algorithm {
+		return errAlgorithmMismatch
+	}
+
+	if stamp.Cost().ExpectedAttempts() < hashcash.BitsCost(opts.bits).ExpectedAttempts() {
+		return errTooEasy
+	}
+
+	if err := stamp.Validate(hashcash.WithMaxAge(opts.expiry), hashcash.WithClockSkew(opts.skew)); err != nil {
+		return err
+	}
+
+	if db != nil && !db.add(stamp) {
+		return errSpent
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// mintStamp returns solved stamp of 8 bits for the resource.
+func mintStamp(t *testing.T, resource string, date time.Time, opts ...hashcash.Option) string {
+	t.Helper()
+
+	stamp, err := hashcash.New(resource, 8, date.Unix(), opts...)
+	if !assert.NoError(t, err) {
+		return ""
+	}
+
+	assert.NoError(t, hashcash.NewSolver(1).Solve(context.Background(), stamp))
+
+	return stamp.ToString()
+}
+
+func TestCheckStamp(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	valid := checkOptions{resource: "alice@example.com", bits: 8, expiry: time.Hour, skew: time.Minute}
+
+	tests := []struct {
+		name    string
+		data    string
+		opts    checkOptions
+		wantErr error
+	}{
+		{
+			name: "valid",
+			data: mintStamp(t, "alice@example.com", now),
+			opts: valid,
+		},
+		{
+			name: "any resource",
+			data: mintStamp(t, "bob@example.com", now),
+			opts: checkOptions{bits: 8},
+		},
+		{
+			name:    "malformed",
+			data:    "3:sha256:8",
+			opts:    valid,
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+		{
+			name:    "another resource",
+			data:    mintStamp(t, "bob@example.com", now),
+			opts:    valid,
+			wantErr: errResourceMismatch,
+		},
+		{
+			name:    "another algorithm",
+			data:    mintStamp(t, "alice@example.com", now, hashcash.WithAlgorithm(hashcash.SHA512)),
+			opts:    checkOptions{resource: "alice@example.com", bits: 8, algorithm: hashcash.SHA256},
+			wantErr: errAlgorithmMismatch,
+		},
+		{
+			name:    "below required bits",
+			data:    mintStamp(t, "alice@example.com", now),
+			opts:    checkOptions{resource: "alice@example.com", bits: 20},
+			wantErr: errTooEasy,
+		},
+		{
+			name:    "expired",
+			data:    mintStamp(t, "alice@example.com", now.Add(-2*time.Hour)),
+			opts:    valid,
+			wantErr: hashcash.ErrExpired,
+		},
+		{
+			name:    "future date",
+			data:    mintStamp(t, "alice@example.com", now.Add(time.Hour)),
+			opts:    valid,
+			wantErr: hashcash.ErrFutureDate,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := checkStamp(tt.data, tt.opts, nil)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	t.Parallel()
+
+	stamp := mintStamp(t, "alice@example.com", time.Now())
+
+	tests := []struct {
+		name    string
+		args    []string
+		in      string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "stamps from arguments",
+			args: []string{"-r", "alice@example.com", "-b", "8", stamp},
+			want: stamp + ": valid\n",
+		},
+		{
+			name:    "stamps from input",
+			args:    []string{"-b", "8"},
+			in:      stamp + "\n\n3:sha256:8\n",
+			want:    stamp + ": valid\n3:sha256:8: invalid: parse stamp: invalid stamp format\n",
+			wantErr: errInvalidStamps,
+		},
+		{
+			name:    "unknown algorithm",
+			args:    []string{"-a", "md5", stamp},
+			wantErr: hashcash.ErrUnknownAlgorithm,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			err := check(tt.args, strings.NewReader(tt.in), &out)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestCheck_Replay(t *testing.T) {
+	t.Parallel()
+
+	stamp := mintStamp(t, "alice@example.com", time.Now())
+	args := []string{"-b", "8", "-db", filepath.Join(t.TempDir(), "spent"), stamp}
+
+	var out bytes.Buffer
+
+	assert.NoError(t, check(args, nil, &out))
+	assert.ErrorIs(t, check(args, nil, &out), errInvalidStamps)
+	assert.Equal(t, stamp+": valid\n"+stamp+": invalid: stamp is already spent\n", out.String())
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// spentDB is double-spend database of checked stamps, stored as text file with one stamp per line.
+// Expired stamps are dropped, when database is saved, because they are rejected by expiration check anyway.
+type spentDB struct {
+	path    string
+	expiry  time.Duration
+	stamps  map[string]struct{}
+	ordered []*hashcash.Stamp
+}
+
+func openSpentDB(path string, expiry time.Duration) (*spentDB, error) {
+	db := &spentDB{
+		path:   path,
+		expiry: expiry,
+		stamps: make(map[string]struct{}),
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return db, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// skip lines, which are not stamps, so damaged database doesn't block checks
+		if stamp, err := hashcash.FromString(strings.TrimSpace(scanner.Text())); err == nil {
+			db.add(stamp)
+		}
+	}
+
+	return db, scanner.Err()
+}
+
+// add records the stamp. Returns false, when it's already spent.
+func (db *spentDB) add(stamp *hashcash.Stamp) bool {
+	data := stamp.ToString()
+	if _, ok := db.stamps[data]; ok {
+		return false
+	}
+
+	db.stamps[data] = struct{}{}
+	db.ordered = append(db.ordered, stamp)
+
+	return true
+}
+
+// save replaces database file with not expired stamps.
+func (db *spentDB) save() error {
+	tmp, err := os.CreateTemp(filepath.Dir(db.path), filepath.Base(db.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	w := bufio.NewWriter(tmp)
+
+	for _, stamp := range db.ordered {
+		if db.expiry > 0 && time.Since(time.Unix(stamp.GetDate(), 0)) > db.expiry {
+			continue
+		}
+
+		if _, err := w.WriteString(stamp.ToString() + "\n"); err != nil {
+			_ = tmp.Close()
+
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), db.path)
+}
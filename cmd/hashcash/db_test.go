@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestOpenSpentDB(t *testing.T) {
+	t.Parallel()
+
+	spent := mintStamp(t, "alice@example.com", time.Now())
+	fresh := mintStamp(t, "bob@example.com", time.Now())
+
+	tests := []struct {
+		name      string
+		content   *string
+		stamp     string
+		wantAdded bool
+	}{
+		{
+			name:      "missing file",
+			stamp:     spent,
+			wantAdded: true,
+		},
+		{
+			name:      "spent stamp",
+			content:   &spent,
+			stamp:     spent,
+			wantAdded: false,
+		},
+		{
+			name:      "not spent stamp",
+			content:   &spent,
+			stamp:     fresh,
+			wantAdded: true,
+		},
+		{
+			name:      "damaged lines are skipped",
+			content:   stringPtr("garbage\n\n  " + spent + "  \n3:sha256"),
+			stamp:     spent,
+			wantAdded: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "spent")
+			if tt.content != nil {
+				assert.NoError(t, os.WriteFile(path, []byte(*tt.content+"\n"), 0o600))
+			}
+
+			db, err := openSpentDB(path, time.Hour)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			stamp, err := hashcash.FromString(tt.stamp)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantAdded, db.add(stamp))
+		})
+	}
+}
+
+func TestSpentDB_Save(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "spent")
+
+	db, err := openSpentDB(path, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	fresh, err := hashcash.FromString(mintStamp(t, "alice@example.com", time.Now()))
+	assert.NoError(t, err)
+
+	expired, err := hashcash.FromString(mintStamp(t, "alice@example.com", time.Now().Add(-2*time.Hour)))
+	assert.NoError(t, err)
+
+	assert.True(t, db.add(fresh))
+	assert.False(t, db.add(fresh))
+	assert.True(t, db.add(expired))
+	assert.NoError(t, db.save())
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, fresh.ToString()+"\n", string(content))
+
+	// replay of saved stamp is detected after reopening
+	db, err = openSpentDB(path, time.Hour)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.False(t, db.add(fresh))
+	assert.True(t, db.add(expired))
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// stampInfo is a description of the stamp, printed by inspect.
+type stampInfo struct {
+	Stamp            string  `json:"stamp"`
+	Error            string  `json:"error,omitempty"`
+	Version          int     `json:"version,omitempty"`
+	Classic          bool    `json:"classic,omitempty"`
+	Algorithm        string  `json:"algorithm,omitempty"`
+	Bits             int     `json:"bits,omitempty"`
+	Target           string  `json:"target,omitempty"`
+	Date             string  `json:"date,omitempty"`
+	Resource         string  `json:"resource,omitempty"`
+	Extension        string  `json:"extension,omitempty"`
+	Rand             string  `json:"rand,omitempty"`
+	Counter          int     `json:"counter,omitempty"`
+	ExpectedAttempts float64 `json:"expected_attempts,omitempty"`
+	Valid            bool    `json:"valid"`
+}
+
+// inspect prints fields of stamps, read from arguments or in, to out, so stamps captured from clients can be debugged.
+// Malformed stamps are described with parse error.
+func inspect(args []string, in io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false, "print stamps as JSON objects, one per line")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	stamps, err := readStamps(flags.Args(), in)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(out)
+
+	for i, data := range stamps {
+		info := describe(data)
+
+		if *asJSON {
+			if err := encoder.Encode(info); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintln(out)
+		}
+
+		if err := printInfo(out, info); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func describe(data string) stampInfo {
+	info := stampInfo{Stamp: data}
+
+	stamp, err := hashcash.FromString(data)
+	if err != nil {
+		info.Error = err.Error()
+
+		return info
+	}
+
+	info.Version = stamp.GetVersion()
+	info.Classic = stamp.IsClassic()
+	info.Algorithm = string(stamp.GetAlgorithm())
+	info.Bits = stamp.GetBits()
+	info.Date = time.Unix(stamp.GetDate(), 0).UTC().Format(time.RFC3339)
+	info.Resource = stamp.GetResource()
+	info.Extension = stamp.GetExtension()
+	info.Rand = stamp.GetRandValue()
+	info.Counter = stamp.GetCounter()
+	info.ExpectedAttempts = stamp.Cost().ExpectedAttempts()
+	info.Valid = stamp.Verify()
+
+	if target := stamp.GetTarget(); target != nil {
+		info.Target = "0x" + target.Text(16)
+	}
+
+	return info
+}
+
+func printInfo(out io.Writer, info stampInfo) error {
+	w := tabwriter.NewWriter(out, 0, 0, 1, ' ', 0)
+
+	fmt.Fprintf(w, "stamp:\t%s\n", info.Stamp)
+
+	if info.Error != "" {
+		fmt.Fprintf(w, "error:\t%s\n", info.Error)
+
+		return w.Flush()
+	}
+
+	difficulty := strconv.Itoa(info.Bits) + " bits"
+	if info.Target != "" {
+		difficulty = "target " + info.Target
+	}
+
+	fmt.Fprintf(w, "version:\t%d\n", info.Version)
+	fmt.Fprintf(w, "classic:\t%t\n", info.Classic)
+	fmt.Fprintf(w, "algorithm:\t%s\n", info.Algorithm)
+	fmt.Fprintf(w, "difficulty:\t%s, %.0f attempts expected\n", difficulty, info.ExpectedAttempts)
+	fmt.Fprintf(w, "date:\t%s\n", info.Date)
+	fmt.Fprintf(w, "resource:\t%q\n", info.Resource)
+
+	if info.Classic {
+		fmt.Fprintf(w, "extension:\t%q\n", info.Extension)
+	}
+
+	fmt.Fprintf(w, "rand:\t%s\n", info.Rand)
+	fmt.Fprintf(w, "counter:\t%d\n", info.Counter)
+	fmt.Fprintf(w, "valid hash:\t%t\n", info.Valid)
+
+	return w.Flush()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInspect(t *testing.T) {
+	t.Parallel()
+
+	// stamp from hashcash.org documentation
+	const stamp = "1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi"
+
+	tests := []struct {
+		name string
+		args []string
+		in   string
+		want string
+	}{
+		{
+			name: "classic stamp",
+			args: []string{stamp},
+			want: "stamp:      " + stamp + "\n" +
+				"version:    1\n" +
+				"classic:    true\n" +
+				"algorithm:  sha1\n" +
+				"difficulty: 20 bits, 1048576 attempts expected\n" +
+				"date:       2013-03-03T06:00:00Z\n" +
+				"resource:   \"adam@cypherspace.org\"\n" +
+				"extension:  \"\"\n" +
+				"rand:       McMybZIhxKXu57jd\n" +
+				"counter:    7490530\n" +
+				"valid hash: true\n",
+		},
+		{
+			name: "malformed stamps from input",
+			in:   "3:sha256\n1:20\n",
+			want: "stamp: 3:sha256\nerror: parse stamp: invalid stamp format\n\nstamp: 1:20\nerror: parse stamp: invalid stamp format\n",
+		},
+		{
+			name: "json",
+			args: []string{"-json", stamp, "3:sha256"},
+			want: `{"stamp":"` + stamp + `","version":1,"classic":true,"algorithm":"sha1","bits":20,` +
+				`"date":"2013-03-03T06:00:00Z","resource":"adam@cypherspace.org","rand":"McMybZIhxKXu57jd",` +
+				`"counter":7490530,"expected_attempts":1048576,"valid":true}` + "\n" +
+				`{"stamp":"3:sha256","error":"parse stamp: invalid stamp format","valid":false}` + "\n",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var out bytes.Buffer
+
+			assert.NoError(t, inspect(tt.args, strings.NewReader(tt.in), &out))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+const usage = `Usage: hashcash <command> [flags] [stamps]
+
+Commands:
+  mint     mint stamp for the resource
+  check    check stamps, read from arguments or stdin, and record them in double-spend database
+  inspect  print fields of stamps, read from arguments or stdin
+
+Run "hashcash <command> -h" for flags of the command.
+`
+
+// Exit codes of the tool.
+const (
+	exitOK      = 0
+	exitInvalid = 1 // exitInvalid is returned, when stamp is invalid or command fails.
+	exitUsage   = 2
+)
+
+var errInvalidStamps = errors.New("invalid stamps")
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(exitUsage)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	code := run(ctx, os.Args[1], os.Args[2:])
+
+	cancel()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, command string, args []string) int {
+	var err error
+
+	switch command {
+	case "mint":
+		err = mint(ctx, args)
+	case "check":
+		err = check(args, os.Stdin, os.Stdout)
+	case "inspect":
+		err = inspect(args, os.Stdin, os.Stdout)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+
+		return exitUsage
+	}
+
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, flag.ErrHelp):
+		return exitUsage
+	case errors.Is(err, errInvalidStamps):
+		return exitInvalid
+	case errors.Is(err, errNoResource), errors.Is(err, hashcash.ErrUnknownAlgorithm):
+		fmt.Fprintf(os.Stderr, "hashcash %s: %s\n", command, err)
+
+		return exitUsage
+	default:
+		fmt.Fprintf(os.Stderr, "hashcash %s: %s\n", command, err)
+
+		return exitInvalid
+	}
+}
+
+// readStamps returns stamps from arguments or from lines of r, when there are no arguments.
+func readStamps(args []string, r io.Reader) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+
+	var stamps []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			stamps = append(stamps, line)
+		}
+	}
+
+	return stamps, scanner.Err()
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+const defaultBits = 20
+
+var errNoResource = errors.New("resource is required")
+
+// mint mints stamp for the resource and prints it to stdout.
+func mint(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("mint", flag.ContinueOnError)
+	resource := flags.String("r", "", "resource of the stamp, e.g. email address")
+	bits := flags.Int("b", defaultBits, "leading zero bits of the stamp hash")
+	algorithm := flags.String("a", string(hashcash.DefaultAlgorithm), "hash algorithm of version 3 stamp")
+	classic := flags.Bool("classic", false, "mint standard Hashcash version 1 stamp, which always uses sha1")
+	ext := flags.String("ext", "", "extension field of classic stamp")
+	verbose := flags.Bool("v", false, "print expected and actual solving time to stderr")
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if *resource == "" {
+		return errNoResource
+	}
+
+	alg, err := hashcash.ParseAlgorithm(*algorithm)
+	if err != nil {
+		return fmt.Errorf("flag -a: %w", err)
+	}
+
+	opts := []hashcash.Option{hashcash.WithAlgorithm(alg)}
+	if *classic {
+		opts = []hashcash.Option{hashcash.WithClassicFormat(*ext)}
+	}
+
+	stamp, err := hashcash.New(*resource, *bits, time.Now().Unix(), opts...)
+	if err != nil {
+		return err
+	}
+
+	var solverOpts []hashcash.SolverOption
+	if *verbose {
+		solverOpts = append(solverOpts, hashcash.WithProgress(0, func(p hashcash.Progress) {
+			fmt.Fprintf(
+				os.Stderr, "minted in %s with %d attempts at %.0f hashes/s, %.0f attempts expected\n",
+				p.Elapsed.Round(time.Millisecond), p.Attempts, p.HashRate, p.ExpectedAttempts,
+			)
+		}))
+	}
+
+	if err := hashcash.NewSolver(0, solverOpts...).Solve(ctx, stamp); err != nil {
+		return err
+	}
+
+	fmt.Println(stamp.ToString())
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/internal/calibrate"
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
+)
+
+var errNotCalibrated = errors.New("guided tour is bound by network latency of guides, it can't be calibrated locally")
+
+func main() {
+	target := flag.Duration("target", time.Second, "target solve time")
+	samples := flag.Int("samples", 100, "number of challenges solved on every difficulty level")
+	levelTime := flag.Duration("level-time", 10*time.Second, "time limit of solving challenges of one difficulty level")
+	puzzles := flag.String("puzzles", "hashcash,balloon,merkle,vdf", "comma separated puzzle types to calibrate")
+	algorithm := flag.String("algorithm", string(hashcash.DefaultAlgorithm), "hash algorithm of hashcash stamps")
+	issued := flag.String("puzzle", puzzle.HashcashName, "puzzle type issued by the server with suggested configuration")
+	output := flag.String("config", "", "file to write suggested server configuration to, stdout by default")
+	flag.Parse()
+
+	alg, err := hashcash.ParseAlgorithm(*algorithm)
+	if err != nil {
+		fmt.Fprintf(flag.CommandLine.Output(), "invalid -algorithm: %s\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	calibrator := calibrate.New(
+		calibrate.WithTarget(*target),
+		calibrate.WithSamples(*samples),
+		calibrate.WithLevelTime(*levelTime),
+		calibrate.WithProgress(func(r calibrate.Result) {
+			log.Printf("%s %s: %d samples, median %s, p99 %s", r.Puzzle, r.Difficulty, len(r.Durations), r.Median(), r.P99())
+		}),
+	)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	config, err := run(ctx, calibrator, strings.Split(*puzzles, ","), alg)
+
+	cancel()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *issued != puzzle.HashcashName {
+		config.Puzzle = *issued
+	}
+
+	if err := writeConfig(*output, config); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// run benchmarks puzzles, prints table of solve times and returns suggested configuration.
+func run(ctx context.Context, calibrator *calibrate.Calibrator, names []string, alg hashcash.Algorithm) (calibrate.Config, error) {
+	config := calibrate.Config{
+		Target: calibrator.GetTarget(),
+	}
+
+	var all []calibrate.Result
+
+	for _, name := range names {
+		series, err := newSeries(strings.TrimSpace(name), alg)
+		if err != nil {
+			return calibrate.Config{}, err
+		}
+
+		results, err := calibrator.Run(ctx, series)
+		if err != nil {
+			return calibrate.Config{}, fmt.Errorf("calibrate %s: %w", series.Name(), err)
+		}
+
+		all = append(all, results...)
+		config.Sections = append(config.Sections, calibrate.Section{
+			Puzzle:   series.Name(),
+			Settings: calibrator.Config(series, results),
+		})
+	}
+
+	if err := calibrate.WriteTable(os.Stdout, all); err != nil {
+		return calibrate.Config{}, err
+	}
+
+	return config, nil
+}
+
+func newSeries(name string, alg hashcash.Algorithm) (calibrate.Series, error) {
+	switch name {
+	case puzzle.HashcashName:
+		return calibrate.NewHashcashSeries(alg), nil
+	case puzzle.BalloonName:
+		return calibrate.NewBalloonSeries(balloon.DefaultParams), nil
+	case puzzle.MerkleName:
+		return calibrate.NewMerkleSeries(merkle.DefaultParams.Proofs), nil
+	case puzzle.VDFName:
+		modulus, err := vdf.GenerateModulus(vdf.DefaultModulusBits)
+		if err != nil {
+			return nil, err
+		}
+
+		return calibrate.NewVDFSeries(modulus), nil
+	case puzzle.TourName:
+		return nil, errNotCalibrated
+	default:
+		return nil, fmt.Errorf("%w: %s", puzzle.ErrUnknownPuzzle, name)
+	}
+}
+
+// writeConfig writes configuration to the file or to stdout, when file name is empty.
+func writeConfig(name string, config calibrate.Config) error {
+	if name == "" {
+		fmt.Println()
+
+		return calibrate.WriteConfig(os.Stdout, config)
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+
+	if err := calibrate.WriteConfig(f, config); err != nil {
+		_ = f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
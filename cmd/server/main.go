@@ -2,15 +2,26 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/SergeySlonimsky/pow/internal/server"
 	"github.com/SergeySlonimsky/pow/internal/server/cache"
 	"github.com/SergeySlonimsky/pow/internal/server/handler"
 	"github.com/SergeySlonimsky/pow/internal/server/pow"
 	"github.com/SergeySlonimsky/pow/internal/server/storage"
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/guidedtour"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
 )
 
 func main() {
@@ -18,17 +29,311 @@ func main() {
 
 	quoteStorage := storage.New()
 
-	redis, err := cache.NewRedisCache(ctx, os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))
+	proofOfWork, err := newPoW(ctx)
 	if err != nil {
-		log.Fatalf("can't connect ro redis: %s", err.Error())
+		log.Fatal(err)
 	}
 
-	proofOfWork := pow.New(redis)
+	var maxFrameSize int
+	if err := envInt("SERVER_MAX_FRAME_SIZE", &maxFrameSize); err != nil {
+		log.Fatal(err)
+	}
 
 	h := handler.New(quoteStorage, proofOfWork)
-	app := server.New(h)
+	app := server.New(h, server.WithMaxFrameSize(maxFrameSize), server.WithPuzzles(proofOfWork.Puzzle()))
 
 	if err := app.Run(ctx, fmt.Sprintf("0.0.0.0:%s", os.Getenv("SERVER_PORT"))); err != nil {
 		log.Fatal(err)
 	}
 }
+
+// newPoW creates stateless PoW when POW_HMAC_KEYS is set, and PoW with redis cache otherwise.
+// Stateless PoW keeps spent stamps in memory, unless REDIS_HOST is set to share them between instances.
+func newPoW(ctx context.Context) (*pow.PoW, error) {
+	opts, err := challengeOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	limits, err := limitOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, limits...)
+
+	if hmacKeys := os.Getenv("POW_HMAC_KEYS"); hmacKeys != "" {
+		keys, err := pow.ParseKeys(hmacKeys)
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_HMAC_KEYS: %w", err)
+		}
+
+		opts = append(opts, pow.WithStatelessKeys(keys...))
+
+		if os.Getenv("REDIS_HOST") == "" {
+			return pow.New(cache.NewMemoryCache(), opts...)
+		}
+	}
+
+	redis, err := cache.NewRedisCache(ctx, os.Getenv("REDIS_HOST"), os.Getenv("REDIS_PORT"))
+	if err != nil {
+		return nil, fmt.Errorf("can't connect ro redis: %w", err)
+	}
+
+	return pow.New(redis, opts...)
+}
+
+// challengeOptions creates options of issued challenges: hashcash stamps or another puzzle, set by POW_PUZZLE.
+func challengeOptions() ([]pow.Option, error) {
+	var opts []pow.Option
+	if name := os.Getenv("POW_ALGORITHM"); name != "" {
+		alg, err := hashcash.ParseAlgorithm(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_ALGORITHM: %w", err)
+		}
+
+		opts = append(opts, pow.WithAlgorithm(alg))
+	}
+
+	switch format := os.Getenv("POW_STAMP_FORMAT"); format {
+	case "":
+	case "classic":
+		opts = append(opts, pow.WithClassicStamps())
+	default:
+		return nil, fmt.Errorf("unknown POW_STAMP_FORMAT %q", format)
+	}
+
+	if solveTime := os.Getenv("POW_SOLVE_TIME"); solveTime != "" {
+		opt, err := newSolveTime(solveTime)
+		if err != nil {
+			return nil, err
+		}
+
+		opts = append(opts, opt)
+	}
+
+	var subStamps int
+	if err := envInt("POW_MULTI_STAMPS", &subStamps); err != nil {
+		return nil, err
+	}
+
+	opts = append(opts, pow.WithMultiStamps(subStamps))
+
+	opt, err := newPuzzle(os.Getenv("POW_PUZZLE"))
+	if err != nil {
+		return nil, err
+	}
+
+	if opt != nil {
+		opts = append(opts, opt)
+	}
+
+	return opts, nil
+}
+
+// newPuzzle creates option of the puzzle, which replaces hashcash stamps.
+// Returns nil option for hashcash stamps, which are issued by default, and error for unknown puzzle.
+func newPuzzle(name string) (pow.Option, error) {
+	switch name {
+	case "", puzzle.HashcashName:
+		return nil, nil
+	case puzzle.BalloonName:
+		return newBalloon()
+	case puzzle.MerkleName:
+		return newMerkle()
+	case puzzle.VDFName:
+		return newVDF()
+	case puzzle.TourName:
+		return newGuidedTour()
+	default:
+		return nil, fmt.Errorf("unknown POW_PUZZLE %q", name)
+	}
+}
+
+// limitOptions creates options, which limit open challenges and accepted stamp dates.
+func limitOptions() ([]pow.Option, error) {
+	var maxOpen int
+	if err := envInt("POW_MAX_OPEN_CHALLENGES", &maxOpen); err != nil {
+		return nil, err
+	}
+
+	opts := []pow.Option{pow.WithMaxOpenChallenges(maxOpen)}
+
+	if maxAge := os.Getenv("POW_MAX_STAMP_AGE"); maxAge != "" {
+		duration, err := time.ParseDuration(maxAge)
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_MAX_STAMP_AGE: %w", err)
+		}
+
+		opts = append(opts, pow.WithMaxStampAge(duration))
+	}
+
+	if skew := os.Getenv("POW_CLOCK_SKEW"); skew != "" {
+		duration, err := time.ParseDuration(skew)
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_CLOCK_SKEW: %w", err)
+		}
+
+		opts = append(opts, pow.WithClockSkew(duration))
+	}
+
+	return opts, nil
+}
+
+// newSolveTime creates option, which sets difficulty of stamps, solved in POW_SOLVE_TIME on average
+// by clients computing POW_CLIENT_HASH_RATE hashes per second.
+func newSolveTime(solveTime string) (pow.Option, error) {
+	duration, err := time.ParseDuration(solveTime)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POW_SOLVE_TIME: %w", err)
+	}
+
+	hashRate, err := strconv.ParseFloat(os.Getenv("POW_CLIENT_HASH_RATE"), 64)
+	if err != nil || hashRate <= 0 {
+		return nil, fmt.Errorf("invalid POW_CLIENT_HASH_RATE: %q", os.Getenv("POW_CLIENT_HASH_RATE"))
+	}
+
+	return pow.WithSolveTime(duration, hashRate), nil
+}
+
+// newBalloon creates Balloon option with POW_BALLOON_SPACE_COST, POW_BALLOON_TIME_COST and POW_BALLOON_BITS,
+// which default to balloon.DefaultParams.
+func newBalloon() (pow.Option, error) {
+	params := balloon.DefaultParams
+
+	if err := envInt("POW_BALLOON_SPACE_COST", &params.SpaceCost); err != nil {
+		return nil, err
+	}
+
+	if err := envInt("POW_BALLOON_TIME_COST", &params.TimeCost); err != nil {
+		return nil, err
+	}
+
+	if err := envInt("POW_BALLOON_BITS", &params.Bits); err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid balloon params: %w", err)
+	}
+
+	return pow.WithPuzzle(puzzle.NewBalloonIssuer(params)), nil
+}
+
+// newMerkle creates Merkle tree option with POW_MERKLE_HEIGHT and POW_MERKLE_PROOFS,
+// which default to merkle.DefaultParams.
+func newMerkle() (pow.Option, error) {
+	params := merkle.DefaultParams
+
+	if err := envInt("POW_MERKLE_HEIGHT", &params.Height); err != nil {
+		return nil, err
+	}
+
+	if err := envInt("POW_MERKLE_PROOFS", &params.Proofs); err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid merkle params: %w", err)
+	}
+
+	return pow.WithPuzzle(puzzle.NewMerkleIssuer(params)), nil
+}
+
+// envInt sets value to integer from environment variable with given name, when it's set.
+func envInt(name string, value *int) error {
+	data := os.Getenv(name)
+	if data == "" {
+		return nil
+	}
+
+	parsed, err := strconv.Atoi(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+
+	*value = parsed
+
+	return nil
+}
+
+// newVDF creates VDF option with POW_VDF_ITERATIONS squarings in group of base64 encoded POW_VDF_MODULUS.
+// Random modulus is generated, when it's not set, so puzzles can't be verified by other instances.
+func newVDF() (pow.Option, error) {
+	iterations := vdf.DefaultIterations
+
+	if err := envInt("POW_VDF_ITERATIONS", &iterations); err != nil {
+		return nil, err
+	}
+
+	var modulus *big.Int
+
+	if encodedModulus := os.Getenv("POW_VDF_MODULUS"); encodedModulus != "" {
+		data, err := base64.StdEncoding.DecodeString(encodedModulus)
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_VDF_MODULUS: %w", err)
+		}
+
+		modulus = new(big.Int).SetBytes(data)
+	} else {
+		var err error
+		if modulus, err = vdf.GenerateModulus(vdf.DefaultModulusBits); err != nil {
+			return nil, err
+		}
+	}
+
+	// invalid modulus, e.g. 0 or 1, and iterations are rejected on start instead of the first issue
+	if err := vdf.ValidateParams(modulus, iterations); err != nil {
+		return nil, fmt.Errorf("invalid vdf params: %w", err)
+	}
+
+	return pow.WithPuzzle(puzzle.NewVDFIssuer(modulus, iterations)), nil
+}
+
+// newGuidedTour creates guided tour option with POW_TOUR_LENGTH visits of guides,
+// which secrets are comma separated base64 strings from POW_TOUR_GUIDE_KEYS.
+// Random guides are generated, when secrets are not set, so tours can't be verified by other instances.
+func newGuidedTour() (pow.Option, error) {
+	length := guidedtour.DefaultLength
+
+	if err := envInt("POW_TOUR_LENGTH", &length); err != nil {
+		return nil, err
+	}
+
+	guideKeys := os.Getenv("POW_TOUR_GUIDE_KEYS")
+	if guideKeys == "" {
+		guides, err := guidedtour.GenerateGuides(guidedtour.DefaultGuides)
+		if err != nil {
+			return nil, err
+		}
+
+		return newTourIssuer(guides, length)
+	}
+
+	var secrets [][]byte
+
+	for _, key := range strings.Split(guideKeys, ",") {
+		secret, err := base64.StdEncoding.DecodeString(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid POW_TOUR_GUIDE_KEYS: %w", err)
+		}
+
+		secrets = append(secrets, secret)
+	}
+
+	guides, err := guidedtour.NewGuides(secrets...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid POW_TOUR_GUIDE_KEYS: %w", err)
+	}
+
+	return newTourIssuer(guides, length)
+}
+
+// newTourIssuer validates tour parameters on start instead of the first issue and creates guided tour option.
+func newTourIssuer(guides *guidedtour.Guides, length int) (pow.Option, error) {
+	if err := guidedtour.ValidateParams(length, guides.Count()); err != nil {
+		return nil, fmt.Errorf("invalid tour params: %w", err)
+	}
+
+	return pow.WithPuzzle(puzzle.NewTourIssuer(guides, length)), nil
+}
@@ -0,0 +1,240 @@
+package calibrate
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+)
+
+const (
+	defaultSamples   = 100
+	defaultLevelTime = 10 * time.Second
+	defaultTarget    = time.Second
+)
+
+// minSamples are solved on every level, even when they take longer than level time.
+const minSamples = 3
+
+// calibrationResource is the resource of benchmarked challenges.
+const calibrationResource = "calibrate"
+
+// Series is a puzzle type with levels of growing difficulty, which are benchmarked one by one.
+type Series interface {
+	// Name returns name of the puzzle type.
+	Name() string
+	// Level returns issuer of challenges of the difficulty level and its description. Returns false after the last level.
+	Level(level int) (puzzle.Issuer, string, bool)
+	// Config returns server settings, which make challenges solved in target time, estimated by results of the levels.
+	Config(results []Result, target time.Duration) []Setting
+}
+
+// Setting is an environment variable of the server configuration.
+type Setting struct {
+	Name  string
+	Value string
+}
+
+// Result is solve times of challenges of one difficulty level.
+type Result struct {
+	Puzzle     string
+	Level      int
+	Difficulty string
+	Durations  []time.Duration // Durations are sorted in ascending order.
+}
+
+// Median returns median solve time.
+func (r Result) Median() time.Duration {
+	return r.Percentile(50)
+}
+
+// P99 returns 99th percentile of solve time.
+// It equals the maximum, unless at least 100 challenges are solved, so it's reported with the number of samples.
+func (r Result) P99() time.Duration {
+	return r.Percentile(99)
+}
+
+// Max returns maximum solve time.
+func (r Result) Max() time.Duration {
+	return r.Percentile(100)
+}
+
+// Percentile returns solve time, which isn't exceeded by given percent of challenges, with nearest-rank method.
+func (r Result) Percentile(percent float64) time.Duration {
+	if len(r.Durations) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(percent / 100 * float64(len(r.Durations))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return r.Durations[rank-1]
+}
+
+// Mean returns average solve time.
+func (r Result) Mean() time.Duration {
+	if len(r.Durations) == 0 {
+		return 0
+	}
+
+	var total time.Duration
+	for _, d := range r.Durations {
+		total += d
+	}
+
+	return total / time.Duration(len(r.Durations))
+}
+
+// ProgressFunc receives result of every benchmarked level.
+type ProgressFunc func(result Result)
+
+// Calibrator benchmarks solving of puzzles on the local machine.
+type Calibrator struct {
+	samples   int
+	levelTime time.Duration
+	target    time.Duration
+	progress  ProgressFunc
+}
+
+// Option configures Calibrator created by New.
+type Option func(c *Calibrator)
+
+// WithSamples sets the number of challenges solved on every level.
+func WithSamples(samples int) Option {
+	return func(c *Calibrator) {
+		c.samples = samples
+	}
+}
+
+// WithLevelTime limits time of solving challenges of one level, so hard levels have less samples.
+func WithLevelTime(levelTime time.Duration) Option {
+	return func(c *Calibrator) {
+		c.levelTime = levelTime
+	}
+}
+
+// WithTarget sets target solve time. Levels are benchmarked until median solve time exceeds twice the target.
+func WithTarget(target time.Duration) Option {
+	return func(c *Calibrator) {
+		c.target = target
+	}
+}
+
+// WithProgress makes Calibrator call fn with result of every benchmarked level.
+func WithProgress(fn ProgressFunc) Option {
+	return func(c *Calibrator) {
+		c.progress = fn
+	}
+}
+
+// New returns Calibrator, which solves 100 challenges on every level within 10 seconds for 1 second target.
+func New(opts ...Option) *Calibrator {
+	c := &Calibrator{
+		samples:   defaultSamples,
+		levelTime: defaultLevelTime,
+		target:    defaultTarget,
+		progress:  func(Result) {},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetTarget returns target solve time.
+func (c *Calibrator) GetTarget() time.Duration {
+	return c.target
+}
+
+// Run benchmarks levels of the series, until median solve time exceeds twice the target or levels are over.
+func (c *Calibrator) Run(ctx context.Context, series Series) ([]Result, error) {
+	var results []Result
+
+	for level := 0; ; level++ {
+		issuer, difficulty, ok := series.Level(level)
+		if !ok {
+			return results, nil
+		}
+
+		result, err := c.measure(ctx, issuer)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Puzzle = series.Name()
+		result.Level = level
+		result.Difficulty = difficulty
+
+		c.progress(result)
+
+		results = append(results, result)
+
+		if result.Median() > 2*c.target {
+			return results, nil
+		}
+	}
+}
+
+// Config returns server settings for the target solve time, estimated by results of the series.
+func (c *Calibrator) Config(series Series, results []Result) []Setting {
+	return series.Config(results, c.target)
+}
+
+// measure solves challenges of the issuer and verifies their solutions.
+func (c *Calibrator) measure(ctx context.Context, issuer puzzle.Issuer) (Result, error) {
+	kind, err := puzzle.Lookup(issuer.Name())
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+
+	started := time.Now()
+
+	for i := 0; i < c.samples && (i < minSamples || time.Since(started) < c.levelTime); i++ {
+		challenge, err := issuer.Issue(calibrationResource, time.Now().Unix(), "")
+		if err != nil {
+			return Result{}, err
+		}
+
+		solveStarted := time.Now()
+
+		solved, err := kind.Solve(ctx, challenge, nil)
+		if err != nil {
+			return Result{}, err
+		}
+
+		result.Durations = append(result.Durations, time.Since(solveStarted))
+
+		if err := issuer.Verify(solved, puzzle.Limits{}); err != nil {
+			return Result{}, err
+		}
+	}
+
+	sort.Slice(result.Durations, func(i, j int) bool { return result.Durations[i] < result.Durations[j] })
+
+	return result, nil
+}
+
+// closest returns result, which median is the closest to the target in logarithmic scale.
+func closest(results []Result, target time.Duration) (Result, bool) {
+	var (
+		best     Result
+		distance = math.Inf(1)
+	)
+
+	for _, result := range results {
+		d := math.Abs(math.Log(float64(result.Median()) / float64(target)))
+		if d < distance {
+			best, distance = result, d
+		}
+	}
+
+	return best, len(results) > 0
+}
@@ -0,0 +1,209 @@
+package calibrate_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/internal/calibrate"
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func durations(ms ...int) []time.Duration {
+	result := make([]time.Duration, 0, len(ms))
+	for _, m := range ms {
+		result = append(result, time.Duration(m)*time.Millisecond)
+	}
+
+	return result
+}
+
+// sequence returns numbers from 1 to n.
+func sequence(n int) []int {
+	result := make([]int, n)
+	for i := range result {
+		result[i] = i + 1
+	}
+
+	return result
+}
+
+func TestResult_Percentile(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		result calibrate.Result
+		median time.Duration
+		p99    time.Duration
+		max    time.Duration
+		mean   time.Duration
+	}{
+		{
+			name: "no samples",
+		},
+		{
+			name:   "odd number of samples",
+			result: calibrate.Result{Durations: durations(1, 2, 3, 4, 10)},
+			median: 3 * time.Millisecond,
+			p99:    10 * time.Millisecond,
+			max:    10 * time.Millisecond,
+			mean:   4 * time.Millisecond,
+		},
+		{
+			name:   "even number of samples",
+			result: calibrate.Result{Durations: durations(1, 2, 3, 6)},
+			median: 2 * time.Millisecond,
+			p99:    6 * time.Millisecond,
+			max:    6 * time.Millisecond,
+			mean:   3 * time.Millisecond,
+		},
+		{
+			name:   "hundreds of samples",
+			result: calibrate.Result{Durations: durations(sequence(200)...)},
+			median: 100 * time.Millisecond,
+			p99:    198 * time.Millisecond,
+			max:    200 * time.Millisecond,
+			mean:   100*time.Millisecond + 500*time.Microsecond,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.median, tt.result.Median())
+			assert.Equal(t, tt.p99, tt.result.P99())
+			assert.Equal(t, tt.max, tt.result.Max())
+			assert.Equal(t, tt.mean, tt.result.Mean())
+		})
+	}
+}
+
+func TestCalibrator_Run(t *testing.T) {
+	t.Parallel()
+
+	var reported []calibrate.Result
+
+	calibrator := calibrate.New(
+		calibrate.WithTarget(time.Nanosecond),
+		calibrate.WithSamples(3),
+		calibrate.WithProgress(func(r calibrate.Result) {
+			reported = append(reported, r)
+		}),
+	)
+
+	series := calibrate.NewHashcashSeries(hashcash.SHA256)
+
+	results, err := calibrator.Run(context.Background(), series)
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Equal(t, results, reported)
+	assert.Equal(t, "hashcash", results[0].Puzzle)
+	assert.Equal(t, "sha256 8 bits", results[0].Difficulty)
+	assert.Len(t, results[0].Durations, 3)
+	assert.IsNonDecreasing(t, results[0].Durations)
+
+	config := calibrator.Config(series, results)
+	assert.Len(t, config, 3)
+	assert.Equal(t, calibrate.Setting{Name: "POW_SOLVE_TIME", Value: "1ns"}, config[1])
+}
+
+func TestSeries_Config(t *testing.T) {
+	t.Parallel()
+
+	results := []calibrate.Result{
+		{Level: 0, Durations: durations(10)},
+		{Level: 1, Durations: durations(20)},
+		{Level: 2, Durations: durations(40)},
+		{Level: 3, Durations: durations(80)},
+	}
+
+	tests := []struct {
+		name   string
+		series calibrate.Series
+		target time.Duration
+		want   []calibrate.Setting
+	}{
+		{
+			name:   "hashcash",
+			series: calibrate.NewHashcashSeries(hashcash.SHA256),
+			target: time.Second,
+			want: []calibrate.Setting{
+				{Name: "POW_ALGORITHM", Value: "sha256"},
+				{Name: "POW_SOLVE_TIME", Value: "1s"},
+				// (256 + 1024 + 4096 + 16384) attempts in 150ms
+				{Name: "POW_CLIENT_HASH_RATE", Value: "145067"},
+			},
+		},
+		{
+			name:   "balloon",
+			series: calibrate.NewBalloonSeries(balloon.Params{SpaceCost: 16, TimeCost: 2}),
+			target: 25 * time.Millisecond,
+			want: []calibrate.Setting{
+				{Name: "POW_BALLOON_SPACE_COST", Value: "16"},
+				{Name: "POW_BALLOON_TIME_COST", Value: "2"},
+				{Name: "POW_BALLOON_BITS", Value: "1"},
+			},
+		},
+		{
+			name:   "merkle",
+			series: calibrate.NewMerkleSeries(4),
+			target: 70 * time.Millisecond,
+			want: []calibrate.Setting{
+				{Name: "POW_MERKLE_HEIGHT", Value: "11"},
+				{Name: "POW_MERKLE_PROOFS", Value: "4"},
+			},
+		},
+		{
+			name:   "vdf is interpolated",
+			series: calibrate.NewVDFSeries(nil),
+			target: 60 * time.Millisecond,
+			want: []calibrate.Setting{
+				{Name: "POW_VDF_ITERATIONS", Value: "6144"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.series.Config(results, tt.target))
+			assert.Empty(t, tt.series.Config(nil, tt.target))
+		})
+	}
+}
+
+func TestWriteConfig(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	err := calibrate.WriteConfig(&buf, calibrate.Config{
+		Target: time.Second,
+		Puzzle: "merkle",
+		Sections: []calibrate.Section{
+			{Puzzle: "merkle", Settings: []calibrate.Setting{{Name: "POW_MERKLE_HEIGHT", Value: "16"}}},
+			{Puzzle: "vdf"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "# Generated by powcalibrate for 1s target solve time.\nPOW_PUZZLE=merkle\n# merkle\nPOW_MERKLE_HEIGHT=16\n", buf.String())
+}
+
+func TestWriteTable(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+
+	err := calibrate.WriteTable(&buf, []calibrate.Result{
+		{Puzzle: "merkle", Difficulty: "height 8", Durations: durations(1, 2, 3)},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "PUZZLE  DIFFICULTY  SAMPLES  MEDIAN  P99  MAX\nmerkle  height 8    3        2ms     3ms  3ms\n", buf.String())
+}
@@ -0,0 +1,81 @@
+package calibrate
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes table of median, 99th percentile and maximum solve times of the results.
+func WriteTable(w io.Writer, results []Result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "PUZZLE\tDIFFICULTY\tSAMPLES\tMEDIAN\tP99\tMAX")
+
+	for _, result := range results {
+		fmt.Fprintf(
+			tw, "%s\t%s\t%d\t%s\t%s\t%s\n",
+			result.Puzzle, result.Difficulty, len(result.Durations), round(result.Median()), round(result.P99()), round(result.Max()),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// Config is configuration of the server, suggested for the target solve time.
+type Config struct {
+	Target   time.Duration
+	Puzzle   string    // Puzzle is issued by the server, POW_PUZZLE is omitted when it's empty.
+	Sections []Section // Sections are settings of calibrated puzzles.
+}
+
+// Section is settings of one puzzle type.
+type Section struct {
+	Puzzle   string
+	Settings []Setting
+}
+
+// WriteConfig writes configuration as environment file, which is loaded by the server, e.g. with docker-compose env_file.
+// Settings of all calibrated puzzles are written, so the issued puzzle can be switched by POW_PUZZLE.
+func WriteConfig(w io.Writer, config Config) error {
+	if _, err := fmt.Fprintf(w, "# Generated by powcalibrate for %s target solve time.\n", config.Target); err != nil {
+		return err
+	}
+
+	if config.Puzzle != "" {
+		if _, err := fmt.Fprintf(w, "POW_PUZZLE=%s\n", config.Puzzle); err != nil {
+			return err
+		}
+	}
+
+	for _, section := range config.Sections {
+		if len(section.Settings) == 0 {
+			continue
+		}
+
+		if _, err := fmt.Fprintf(w, "# %s\n", section.Puzzle); err != nil {
+			return err
+		}
+
+		for _, setting := range section.Settings {
+			if _, err := fmt.Fprintf(w, "%s=%s\n", setting.Name, setting.Value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// round rounds duration for display in the table.
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(time.Microsecond)
+	default:
+		return d
+	}
+}
@@ -0,0 +1,196 @@
+package calibrate
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
+)
+
+// Difficulty ranges of benchmarked levels.
+const (
+	minHashcashBits  = 8
+	maxHashcashBits  = 40
+	hashcashBitsStep = 2
+	minMerkleHeight  = 8
+	minVDFLog2       = 10
+)
+
+type hashcashSeries struct {
+	algorithm hashcash.Algorithm
+}
+
+// NewHashcashSeries returns Series of hashcash stamps with given algorithm and growing leading zero bits.
+// Configuration sets hash rate, measured on all levels, so server chooses difficulty for the target solve time.
+func NewHashcashSeries(alg hashcash.Algorithm) Series {
+	return hashcashSeries{
+		algorithm: alg,
+	}
+}
+
+func (hashcashSeries) Name() string {
+	return puzzle.HashcashName
+}
+
+func (s hashcashSeries) Level(level int) (puzzle.Issuer, string, bool) {
+	bits := hashcashBits(level)
+	if bits > maxHashcashBits {
+		return nil, "", false
+	}
+
+	return puzzle.NewHashcashIssuer(bits, hashcash.WithAlgorithm(s.algorithm)), fmt.Sprintf("%s %d bits", s.algorithm, bits), true
+}
+
+func (s hashcashSeries) Config(results []Result, target time.Duration) []Setting {
+	var (
+		attempts float64
+		elapsed  time.Duration
+	)
+
+	for _, result := range results {
+		attempts += hashcash.BitsCost(hashcashBits(result.Level)).ExpectedAttempts() * float64(len(result.Durations))
+
+		for _, d := range result.Durations {
+			elapsed += d
+		}
+	}
+
+	if elapsed <= 0 {
+		return nil
+	}
+
+	return []Setting{
+		{Name: "POW_ALGORITHM", Value: string(s.algorithm)},
+		{Name: "POW_SOLVE_TIME", Value: target.String()},
+		{Name: "POW_CLIENT_HASH_RATE", Value: strconv.FormatFloat(attempts/elapsed.Seconds(), 'f', 0, 64)},
+	}
+}
+
+func hashcashBits(level int) int {
+	return minHashcashBits + level*hashcashBitsStep
+}
+
+type balloonSeries struct {
+	params balloon.Params
+}
+
+// NewBalloonSeries returns Series of Balloon puzzles with space and time cost of the params and growing bits.
+func NewBalloonSeries(params balloon.Params) Series {
+	return balloonSeries{
+		params: params,
+	}
+}
+
+func (balloonSeries) Name() string {
+	return puzzle.BalloonName
+}
+
+func (s balloonSeries) Level(level int) (puzzle.Issuer, string, bool) {
+	params := s.params
+	params.Bits = level
+
+	if params.Validate() != nil {
+		return nil, "", false
+	}
+
+	return puzzle.NewBalloonIssuer(params), fmt.Sprintf("%d bits", params.Bits), true
+}
+
+func (s balloonSeries) Config(results []Result, target time.Duration) []Setting {
+	best, ok := closest(results, target)
+	if !ok {
+		return nil
+	}
+
+	return []Setting{
+		{Name: "POW_BALLOON_SPACE_COST", Value: strconv.Itoa(s.params.SpaceCost)},
+		{Name: "POW_BALLOON_TIME_COST", Value: strconv.Itoa(s.params.TimeCost)},
+		{Name: "POW_BALLOON_BITS", Value: strconv.Itoa(best.Level)},
+	}
+}
+
+type merkleSeries struct {
+	proofs int
+}
+
+// NewMerkleSeries returns Series of Merkle tree puzzles with given number of proofs and growing tree height.
+func NewMerkleSeries(proofs int) Series {
+	return merkleSeries{
+		proofs: proofs,
+	}
+}
+
+func (merkleSeries) Name() string {
+	return puzzle.MerkleName
+}
+
+func (s merkleSeries) Level(level int) (puzzle.Issuer, string, bool) {
+	params := merkle.Params{Height: minMerkleHeight + level, Proofs: s.proofs}
+	if params.Validate() != nil {
+		return nil, "", false
+	}
+
+	return puzzle.NewMerkleIssuer(params), fmt.Sprintf("height %d", params.Height), true
+}
+
+func (s merkleSeries) Config(results []Result, target time.Duration) []Setting {
+	best, ok := closest(results, target)
+	if !ok {
+		return nil
+	}
+
+	return []Setting{
+		{Name: "POW_MERKLE_HEIGHT", Value: strconv.Itoa(minMerkleHeight + best.Level)},
+		{Name: "POW_MERKLE_PROOFS", Value: strconv.Itoa(s.proofs)},
+	}
+}
+
+type vdfSeries struct {
+	modulus *big.Int
+}
+
+// NewVDFSeries returns Series of VDF puzzles in group of the modulus with doubling number of squarings.
+// Solve time is linear in the number of squarings, so configuration interpolates it for the target solve time.
+func NewVDFSeries(modulus *big.Int) Series {
+	return vdfSeries{
+		modulus: modulus,
+	}
+}
+
+func (vdfSeries) Name() string {
+	return puzzle.VDFName
+}
+
+func (s vdfSeries) Level(level int) (puzzle.Issuer, string, bool) {
+	iterations := vdfIterations(level)
+	if iterations > vdf.MaxIterations {
+		return nil, "", false
+	}
+
+	return puzzle.NewVDFIssuer(s.modulus, iterations), fmt.Sprintf("%d squarings", iterations), true
+}
+
+func (s vdfSeries) Config(results []Result, target time.Duration) []Setting {
+	best, ok := closest(results, target)
+	if !ok || best.Median() <= 0 {
+		return nil
+	}
+
+	iterations := float64(vdfIterations(best.Level)) * float64(target) / float64(best.Median())
+	iterations = math.Max(1, math.Min(math.Round(iterations), vdf.MaxIterations))
+
+	return []Setting{
+		{Name: "POW_VDF_ITERATIONS", Value: strconv.Itoa(int(iterations))},
+	}
+}
+
+func vdfIterations(level int) int {
+	return 1 << (minVDFLog2 + level)
+}
@@ -7,65 +7,342 @@ import (
 	"io"
 	"log"
 	"net"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/SergeySlonimsky/pow/pkg/hashcash"
 	"github.com/SergeySlonimsky/pow/pkg/protocol"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
 )
 
-const defaultGenerationAttempts = 999999
+const defaultSolveTimeout = time.Minute
 
-func Run(_ context.Context, addr string) error { //nolint:gocyclo,cyclop // has to be refactored
-	conn, err := net.Dial("tcp", addr)
+const defaultProgressInterval = 2 * time.Second
+
+// Option configures client Run.
+type Option func(o *options)
+
+type options struct {
+	version      protocol.Version
+	maxFrameSize int
+	quotes       int
+}
+
+// WithProtocolVersion sets the only wire format version, which client offers on handshake.
+// Versions protocol.V3, protocol.V2 and protocol.V1 are offered by default, and the newest one is preferred.
+func WithProtocolVersion(version protocol.Version) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithMaxFrameSize limits size of messages, read from and written to the server.
+func WithMaxFrameSize(size int) Option {
+	return func(o *options) {
+		o.maxFrameSize = size
+	}
+}
+
+// WithQuotes sets number of quotes, requested over one connection. With protocol.V3 challenges of all quotes
+// are requested at once and responses are matched by message ID, older versions request quotes one by one.
+func WithQuotes(count int) Option {
+	return func(o *options) {
+		o.quotes = count
+	}
+}
+
+// session keeps encoder and decoder of the connection, so buffered bytes aren't lost between messages.
+type session struct {
+	encoder      *protocol.Encoder
+	decoder      *protocol.Decoder
+	capabilities protocol.Capabilities
+	quotes       int
+	// pending keeps messages, which were read while waiting for response to another request
+	pending []protocol.Message
+}
+
+// newSession returns session, which sends hello message and reads reply to it with protocol.HelloVersion.
+func newSession(conn net.Conn, opts []Option) *session {
+	o := options{
+		maxFrameSize: protocol.DefaultMaxFrameSize,
+		quotes:       1,
+	}
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	if o.quotes < 1 {
+		o.quotes = 1
+	}
+
+	versions := []protocol.Version{protocol.V3, protocol.V2, protocol.V1}
+	if o.version != protocol.VersionDetect {
+		versions = []protocol.Version{o.version}
+	}
+
+	codecOpts := []protocol.CodecOption{protocol.WithVersion(protocol.HelloVersion), protocol.WithMaxFrameSize(o.maxFrameSize)}
+
+	return &session{
+		encoder: protocol.NewEncoder(conn, codecOpts...),
+		decoder: protocol.NewDecoder(conn, codecOpts...),
+		capabilities: protocol.Capabilities{
+			Versions:  versions,
+			Puzzles:   puzzle.Names(),
+			Encodings: []string{protocol.EncodingText},
+		},
+		quotes: o.quotes,
+	}
+}
+
+// handshake offers client capabilities to the server and switches the session to the version, chosen by the server.
+// Session keeps protocol.HelloVersion, when server replies with error message without code.
+// Servers without handshake support may close connection instead, which is returned as error, see connect.
+func (s *session) handshake() error {
+	hello, err := protocol.NewHello(s.capabilities)
+	if err != nil {
+		return err
+	}
+
+	if err := s.sendMessage(hello); err != nil {
+		return err
+	}
+
+	reply, err := s.readMessage()
+	if err != nil {
+		return err
+	}
+
+	if reply.GetType() == protocol.TypeErr {
+		// error without code isn't reply of server with handshake support
+		if protoErr := protocol.ParseError(reply); protoErr.Code != protocol.CodeUnknown {
+			return protoErr
+		}
+
+		log.Printf("server doesn't support handshake: %s", reply.GetBody())
+
+		return nil
+	}
+
+	server, err := protocol.ParseHello(reply)
+	if err != nil {
+		return err
+	}
+
+	// server must choose from the offered capabilities
+	chosen, err := s.capabilities.Negotiate(server)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("protocol v%d negotiated, server puzzles: %v", chosen.Version(), chosen.Puzzles)
+
+	s.encoder.SetVersion(chosen.Version())
+	s.decoder.SetVersion(chosen.Version())
+
+	return nil
+}
+
+// Run requests challenge from the server at addr, solves it and logs received quote.
+// Error messages of the server are returned as *protocol.Error, which can be matched with errors.Is
+// against protocol errors, e.g. protocol.ErrRateLimited.
+func Run(ctx context.Context, addr string, opts ...Option) error {
+	conn, s, err := connect(addr, opts)
 	if err != nil {
 		return err
 	}
 
 	defer conn.Close()
 
-	if err := sendMessage(conn, protocol.NewMessage(protocol.TypeChallenge, "")); err != nil {
-		return fmt.Errorf("send message: %s", err)
+	// responses of older versions have no ID, so they can't be matched with requests, sent at once
+	batch := 1
+	if s.encoder.Version() >= protocol.V3 {
+		batch = s.quotes
 	}
 
-	for {
-		msg, err := readMessage(conn)
+	for requested := 0; requested < s.quotes; requested += batch {
+		for i := requested; i < requested+batch; i++ {
+			request := protocol.NewMessage(protocol.TypeChallenge, "").WithID(strconv.Itoa(i + 1))
+			if err := s.sendMessage(request); err != nil {
+				return fmt.Errorf("send message: %s", err)
+			}
+		}
+
+		if err := s.receiveQuotes(ctx, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// connect dials the server at addr and makes handshake. Servers without handshake support, e.g. the first release
+// of the server, close connection on unknown hello message, so connect dials again and keeps protocol.HelloVersion.
+func connect(addr string, opts []Option) (net.Conn, *session, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s := newSession(conn, opts)
+
+	err = s.handshake()
+	if err == nil {
+		return conn, s, nil
+	}
+
+	_ = conn.Close()
+
+	if !isClosed(err) {
+		return nil, nil, fmt.Errorf("handshake: %w", err)
+	}
+
+	log.Printf("server closed connection on handshake, reconnecting without it: %s", err)
+
+	if conn, err = net.Dial("tcp", addr); err != nil {
+		return nil, nil, err
+	}
+
+	return conn, newSession(conn, opts), nil
+}
+
+// isClosed reports whether err is caused by connection, closed by the server.
+func isClosed(err error) bool {
+	return errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// receiveQuotes solves received challenges and sends their solutions in reply to them until count quotes are received.
+func (s *session) receiveQuotes(ctx context.Context, count int) error {
+	for received := 0; received < count; {
+		msg, err := s.readMessage()
 		if err != nil {
-			return sendMessage(conn, createErrorMessage(err))
+			return s.sendMessage(createErrorMessage(err))
 		}
 
-		switch msg.GetType() {
+		switch msg.GetType() { //nolint:exhaustive // other messages are not expected in reply to requests
 		case protocol.TypeChallenge:
 			log.Printf("challenge received: %s", msg.ToString())
 
-			stamp, err := hashcash.FromString(msg.GetBody())
+			solution, err := solve(ctx, s, msg)
 			if err != nil {
-				return sendMessage(conn, createErrorMessage(err))
+				return s.sendMessage(createErrorMessage(err))
 			}
 
-			if err := stamp.GenerateHash(defaultGenerationAttempts); err != nil {
-				return sendMessage(conn, createErrorMessage(err))
-			}
-
-			if err := sendMessage(conn, protocol.NewMessage(protocol.TypeResource, stamp.ToString())); err != nil {
+			if err := s.sendMessage(protocol.NewMessage(protocol.TypeResource, solution).InReplyTo(msg)); err != nil {
 				return fmt.Errorf("send message: %s", err)
 			}
 		case protocol.TypeResource:
 			log.Printf("Quote received: %s", msg.GetBody())
 
-			return nil
+			received++
+		case protocol.TypeErr:
+			return protocol.ParseError(msg)
+		}
+	}
+
+	return nil
+}
+
+// solve solves challenge of any registered puzzle type until solution is found or defaultSolveTimeout exceeded.
+// Interactive puzzles exchange intermediate challenges with the server over the connection in reply to challenge message.
+func solve(ctx context.Context, s *session, msg protocol.Message) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, defaultSolveTimeout)
+	defer cancel()
+
+	kind, parsed, err := puzzle.Parse(msg.GetBody())
+	if err != nil {
+		return "", err
+	}
+
+	switch kind.Name() {
+	case puzzle.HashcashName:
+		kind = puzzle.NewHashcash(hashcash.WithProgress(defaultProgressInterval, logProgress))
+	case puzzle.MultiStampName:
+		kind = puzzle.NewMultiStamp(hashcash.WithProgress(defaultProgressInterval, logProgress))
+	}
+
+	solution, err := kind.Solve(ctx, parsed, exchange(s, msg))
+	if err != nil {
+		return "", err
+	}
+
+	return solution.ToString(), nil
+}
+
+// exchange returns puzzle.Exchange, which sends intermediate challenge to the server as guide message in reply to request.
+func exchange(s *session, request protocol.Message) puzzle.Exchange {
+	return func(ctx context.Context, challenge puzzle.Challenge) (puzzle.Challenge, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := s.sendMessage(protocol.NewMessage(protocol.TypeGuide, challenge.ToString()).InReplyTo(request)); err != nil {
+			return nil, err
+		}
+
+		msg, err := s.readReply(request.GetID())
+		if err != nil {
+			return nil, err
+		}
+
+		switch msg.GetType() { //nolint:exhaustive // other messages are not expected during exchange
+		case protocol.TypeGuide:
+			_, next, err := puzzle.Parse(msg.GetBody())
+
+			return next, err
 		case protocol.TypeErr:
-			return errors.New(msg.GetBody())
+			return nil, protocol.ParseError(msg)
+		default:
+			return nil, fmt.Errorf("unexpected message: %s", msg.GetType())
 		}
 	}
 }
 
-func readMessage(r io.Reader) (protocol.Message, error) {
-	return protocol.ParseFromReader(r)
+func logProgress(p hashcash.Progress) {
+	if p.Done {
+		log.Printf("solving finished: %d attempts in %s, %.0f H/s", p.Attempts, p.Elapsed, p.HashRate)
+
+		return
+	}
+
+	log.Printf(
+		"solving: %d/%.0f expected attempts, %.0f H/s, %s remaining",
+		p.Attempts, p.ExpectedAttempts, p.HashRate, p.Remaining.Round(time.Second),
+	)
+}
+
+// readMessage returns pending message or reads the next one.
+func (s *session) readMessage() (protocol.Message, error) {
+	if len(s.pending) > 0 {
+		msg := s.pending[0]
+		s.pending = s.pending[1:]
+
+		return msg, nil
+	}
+
+	return s.decoder.Decode()
 }
 
-func sendMessage(conn net.Conn, msg protocol.Message) error {
-	text := fmt.Sprintf("%s\n", msg.ToString())
+// readReply reads messages until message with given ID and keeps other messages pending.
+// Error messages without ID are returned too, as server can't match them with request.
+func (s *session) readReply(id string) (protocol.Message, error) {
+	for {
+		msg, err := s.decoder.Decode()
+		if err != nil {
+			return protocol.Message{}, err
+		}
+
+		if msg.GetID() == id || (msg.GetType() == protocol.TypeErr && msg.GetID() == "") {
+			return msg, nil
+		}
+
+		s.pending = append(s.pending, msg)
+	}
+}
 
-	if _, err := conn.Write([]byte(text)); err != nil {
+func (s *session) sendMessage(msg protocol.Message) error {
+	if err := s.encoder.Encode(msg); err != nil {
 		return fmt.Errorf("error writing data: %s", err.Error())
 	}
 
@@ -73,5 +350,5 @@ func sendMessage(conn net.Conn, msg protocol.Message) error {
 }
 
 func createErrorMessage(err error) protocol.Message {
-	return protocol.NewMessage(protocol.TypeErr, err.Error())
+	return protocol.NewError(protocol.CodeInternal, err.Error()).ToMessage()
 }
@@ -0,0 +1,108 @@
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/internal/client"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// serveWithoutHandshake serves connections like server without handshake support: it reads V1 messages,
+// closes connection or replies with error on unknown hello message, sends easy stamps and quotes.
+func serveWithoutHandshake(listener net.Listener, closeOnHello bool, conns *int32) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		atomic.AddInt32(conns, 1)
+
+		go func() {
+			defer conn.Close()
+
+			reader := bufio.NewReader(conn)
+
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+
+				reply := "resource|test quote\n"
+
+				switch strings.SplitN(line, "|", 2)[0] {
+				case "hello":
+					if closeOnHello {
+						return
+					}
+
+					reply = "error|unknown message type\n"
+				case "challenge":
+					stamp, err := hashcash.New("127.0.0.1", 1, time.Now().Unix())
+					if err != nil {
+						return
+					}
+
+					reply = "challenge|" + stamp.ToString() + "\n"
+				}
+
+				if _, err := conn.Write([]byte(reply)); err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+func TestRun_WithoutHandshake(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		closeOnHello bool
+		wantConns    int32
+	}{
+		{
+			name:         "server closes connection on hello",
+			closeOnHello: true,
+			wantConns:    2,
+		},
+		{
+			name:         "server replies with error on hello",
+			closeOnHello: false,
+			wantConns:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := net.Listen("tcp", "127.0.0.1:0")
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			defer listener.Close()
+
+			var conns int32
+
+			go serveWithoutHandshake(listener, tt.closeOnHello, &conns)
+
+			err = client.Run(context.Background(), listener.Addr().String(), client.WithQuotes(2))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantConns, atomic.LoadInt32(&conns))
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memorySweepInterval is the minimal interval between removals of all expired keys.
+const memorySweepInterval = time.Minute
+
+var (
+	ErrNotFound   = errors.New("key not found")
+	ErrNotInteger = errors.New("value is not an integer")
+)
+
+type memoryItem struct {
+	value     string
+	expiresAt time.Time
+}
+
+// Memory is an in-process cache with the same semantics as Redis, for single instance deployments.
+type Memory struct {
+	mu        sync.Mutex
+	items     map[string]memoryItem
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryCache() *Memory {
+	return &Memory{
+		items: make(map[string]memoryItem),
+		now:   time.Now,
+	}
+}
+
+func (m *Memory) Add(_ context.Context, key, value string, ttl time.Duration) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.set(key, value, ttl)
+
+	return nil
+}
+
+// AddNX adds value only when key does not exist. Returns false when key already exists.
+func (m *Memory) AddNX(_ context.Context, key, value string, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.lookup(key); ok {
+		return false, nil
+	}
+
+	m.set(key, value, ttl)
+
+	return true, nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	return item.value, nil
+}
+
+// GetDel atomically gets value and deletes the key, so value can be read only once.
+func (m *Memory) GetDel(_ context.Context, key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(key)
+	if !ok {
+		return "", ErrNotFound
+	}
+
+	delete(m.items, key)
+
+	return item.value, nil
+}
+
+// Incr increments counter and prolongs its TTL.
+func (m *Memory) Incr(_ context.Context, key string, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	counter, err := m.counter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	counter++
+	m.set(key, strconv.FormatInt(counter, 10), ttl)
+
+	return counter, nil
+}
+
+// Decr decrements existing counter, keeping its TTL. Returns 0 when counter does not exist.
+func (m *Memory) Decr(_ context.Context, key string) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.lookup(key)
+	if !ok {
+		return 0, nil
+	}
+
+	counter, err := m.counter(key)
+	if err != nil {
+		return 0, err
+	}
+
+	counter--
+	item.value = strconv.FormatInt(counter, 10)
+	m.items[key] = item
+
+	return counter, nil
+}
+
+// counter returns integer value of the key or 0 when key does not exist. Must be called with locked mutex.
+func (m *Memory) counter(key string) (int64, error) {
+	item, ok := m.lookup(key)
+	if !ok {
+		return 0, nil
+	}
+
+	counter, err := strconv.ParseInt(item.value, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+
+	return counter, nil
+}
+
+// lookup returns not expired item. Must be called with locked mutex.
+func (m *Memory) lookup(key string) (memoryItem, bool) {
+	item, ok := m.items[key]
+	if !ok {
+		return memoryItem{}, false
+	}
+
+	if !m.now().Before(item.expiresAt) {
+		delete(m.items, key)
+
+		return memoryItem{}, false
+	}
+
+	return item, true
+}
+
+// set stores item and removes expired ones at most once per memorySweepInterval. Must be called with locked mutex.
+func (m *Memory) set(key, value string, ttl time.Duration) {
+	now := m.now()
+
+	if now.Sub(m.lastSweep) >= memorySweepInterval {
+		for k, item := range m.items {
+			if !now.Before(item.expiresAt) {
+				delete(m.items, k)
+			}
+		}
+
+		m.lastSweep = now
+	}
+
+	m.items[key] = memoryItem{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+}
@@ -0,0 +1,86 @@
+package cache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/internal/server/cache"
+)
+
+func TestMemory(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memory := cache.NewMemoryCache()
+
+	assert.NoError(t, memory.Add(ctx, "key", "value", time.Minute))
+
+	value, err := memory.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	value, err = memory.GetDel(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = memory.GetDel(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	added, err := memory.AddNX(ctx, "key", "first", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, added)
+
+	added, err = memory.AddNX(ctx, "key", "second", time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, added)
+
+	value, err = memory.Get(ctx, "key")
+	assert.NoError(t, err)
+	assert.Equal(t, "first", value)
+}
+
+func TestMemory_Expired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memory := cache.NewMemoryCache()
+
+	assert.NoError(t, memory.Add(ctx, "key", "value", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+
+	_, err := memory.Get(ctx, "key")
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+
+	added, err := memory.AddNX(ctx, "key", "value", time.Minute)
+	assert.NoError(t, err)
+	assert.True(t, added)
+}
+
+func TestMemory_Counter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	memory := cache.NewMemoryCache()
+
+	counter, err := memory.Decr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), counter)
+
+	for i := int64(1); i <= 3; i++ {
+		counter, err = memory.Incr(ctx, "counter", time.Minute)
+		assert.NoError(t, err)
+		assert.Equal(t, i, counter)
+	}
+
+	counter, err = memory.Decr(ctx, "counter")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), counter)
+
+	assert.NoError(t, memory.Add(ctx, "key", "value", time.Minute))
+
+	_, err = memory.Incr(ctx, "key", time.Minute)
+	assert.ErrorIs(t, err, cache.ErrNotInteger)
+}
@@ -2,12 +2,21 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 )
 
+// decrExistingScript decrements only existing counter, so expired counter is not recreated without TTL.
+var decrExistingScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("DECR", KEYS[1])
+end
+return 0
+`)
+
 type Redis struct {
 	client *redis.Client
 }
@@ -32,6 +41,47 @@ func (r *Redis) Add(ctx context.Context, key, value string, ttl time.Duration) e
 	return r.client.Set(ctx, key, value, ttl).Err()
 }
 
+// AddNX adds value only when key does not exist. Returns false when key already exists.
+func (r *Redis) AddNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error) {
+	return r.client.SetNX(ctx, key, value, ttl).Result()
+}
+
 func (r *Redis) Get(ctx context.Context, key string) (string, error) {
-	return r.client.Get(ctx, key).Result()
+	return r.get(r.client.Get(ctx, key))
+}
+
+// GetDel atomically gets value and deletes the key, so value can be read only once. Requires Redis 6.2+.
+func (r *Redis) GetDel(ctx context.Context, key string) (string, error) {
+	return r.get(r.client.GetDel(ctx, key))
+}
+
+// Incr increments counter and prolongs its TTL.
+func (r *Redis) Incr(ctx context.Context, key string, ttl time.Duration) (int64, error) {
+	var incr *redis.IntCmd
+
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		incr = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, ttl)
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return incr.Val(), nil
+}
+
+// Decr decrements existing counter, keeping its TTL. Returns 0 when counter does not exist.
+func (r *Redis) Decr(ctx context.Context, key string) (int64, error) {
+	return decrExistingScript.Run(ctx, r.client, []string{key}).Int64()
+}
+
+func (r *Redis) get(cmd *redis.StringCmd) (string, error) {
+	value, err := cmd.Result()
+	if errors.Is(err, redis.Nil) {
+		return "", ErrNotFound
+	}
+
+	return value, err
 }
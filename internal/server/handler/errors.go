@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/internal/server/cache"
+	proofofwork "github.com/SergeySlonimsky/pow/internal/server/pow"
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+)
+
+// protocolError maps errors, caused by client, to protocol errors with their description.
+// Other errors are returned as is, so server reports them as internal without details.
+func protocolError(err error) error {
+	code, ok := errorCode(err)
+	if !ok {
+		return err
+	}
+
+	return protocol.NewError(code, err.Error())
+}
+
+func errorCode(err error) (protocol.ErrorCode, bool) {
+	switch {
+	case errors.Is(err, proofofwork.ErrTooManyOpenChallenges):
+		return protocol.CodeRateLimited, true
+	case errors.Is(err, puzzle.ErrExpired),
+		errors.Is(err, cache.ErrNotFound):
+		return protocol.CodeChallengeExpired, true
+	case errors.Is(err, puzzle.ErrInvalidChallenge),
+		errors.Is(err, proofofwork.ErrParamsMismatch),
+		errors.Is(err, proofofwork.ErrNotInteractive),
+		errors.Is(err, ErrInvalidType):
+		return protocol.CodeInvalidFormat, true
+	case errors.Is(err, puzzle.ErrInvalidSolution),
+		errors.Is(err, proofofwork.ErrResourceMismatch),
+		errors.Is(err, proofofwork.ErrStampSpent),
+		errors.Is(err, proofofwork.ErrUnknownKey),
+		errors.Is(err, proofofwork.ErrInvalidSignature):
+		return protocol.CodeInvalidSolution, true
+	default:
+		return "", false
+	}
+}
@@ -86,6 +86,21 @@ func (mr *MockpowMockRecorder) Generate(ctx, resource interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Generate", reflect.TypeOf((*Mockpow)(nil).Generate), ctx, resource)
 }
 
+// Guide mocks base method.
+func (m *Mockpow) Guide(ctx context.Context, resource, data string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Guide", ctx, resource, data)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Guide indicates an expected call of Guide.
+func (mr *MockpowMockRecorder) Guide(ctx, resource, data interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Guide", reflect.TypeOf((*Mockpow)(nil).Guide), ctx, resource, data)
+}
+
 // Verify mocks base method.
 func (m *Mockpow) Verify(ctx context.Context, resource, data string) error {
 	m.ctrl.T.Helper()
@@ -20,6 +20,7 @@ type quoteStorage interface {
 type pow interface {
 	Generate(ctx context.Context, resource string) (string, error)
 	Verify(ctx context.Context, resource, data string) error
+	Guide(ctx context.Context, resource, data string) (string, error)
 }
 
 type QuoteHandler struct {
@@ -36,15 +37,29 @@ func New(quoteStorage quoteStorage, pow pow) *QuoteHandler {
 
 //nolint:ireturn // to implement handler interface
 // Handle handles request from clients, and determines, which child handler should be run.
+// Errors, caused by client, are returned as *protocol.Error with code.
 func (h *QuoteHandler) Handle(ctx context.Context, req server.Request) (server.Response, error) {
+	var (
+		resp protocol.Message
+		err  error
+	)
+
 	switch req.GetMessage().GetType() { //nolint:exhaustive // protocol.TypeErr should return err
 	case protocol.TypeResource:
-		return h.handleResource(ctx, req)
+		resp, err = h.handleResource(ctx, req)
 	case protocol.TypeChallenge:
-		return h.handleChallenge(ctx, req)
+		resp, err = h.handleChallenge(ctx, req)
+	case protocol.TypeGuide:
+		resp, err = h.handleGuide(ctx, req)
 	default:
-		return nil, ErrInvalidType
+		err = ErrInvalidType
+	}
+
+	if err != nil {
+		return nil, protocolError(err)
 	}
+
+	return resp, nil
 }
 
 func (h *QuoteHandler) handleResource(ctx context.Context, req server.Request) (protocol.Message, error) {
@@ -69,3 +84,14 @@ func (h *QuoteHandler) handleChallenge(ctx context.Context, req server.Request)
 
 	return protocol.NewMessage(protocol.TypeChallenge, challenge), nil
 }
+
+func (h *QuoteHandler) handleGuide(ctx context.Context, req server.Request) (protocol.Message, error) {
+	log.Printf("called \"handleGuide\": with %s", req.GetMessage().ToString())
+
+	tour, err := h.pow.Guide(ctx, req.GetAddr(), req.GetMessage().GetBody())
+	if err != nil {
+		return protocol.Message{}, err
+	}
+
+	return protocol.NewMessage(protocol.TypeGuide, tour), nil
+}
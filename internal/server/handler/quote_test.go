@@ -9,9 +9,12 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/SergeySlonimsky/pow/internal/server"
+	"github.com/SergeySlonimsky/pow/internal/server/cache"
 	"github.com/SergeySlonimsky/pow/internal/server/handler"
 	mockHandler "github.com/SergeySlonimsky/pow/internal/server/handler/mock"
+	proofofwork "github.com/SergeySlonimsky/pow/internal/server/pow"
 	"github.com/SergeySlonimsky/pow/pkg/protocol"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
 )
 
 type testRequest struct {
@@ -162,4 +165,139 @@ func TestQuoteHandler_Handle(t *testing.T) {
 			})
 		}
 	})
+
+	t.Run("handleGuide", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			mockFunc func(ctrl *gomock.Controller) (server.Request, server.Response, *mockHandler.Mockpow, *mockHandler.MockquoteStorage)
+			wantErr  bool
+		}{
+			{
+				name: "visited guide",
+				mockFunc: func(ctrl *gomock.Controller) (server.Request, server.Response, *mockHandler.Mockpow, *mockHandler.MockquoteStorage) {
+					req := testRequest{
+						message: protocol.NewMessage(protocol.TypeGuide, "tour"),
+						addr:    "192.168.1.1",
+					}
+					resp := protocol.NewMessage(protocol.TypeGuide, "extended tour")
+
+					pow := mockHandler.NewMockpow(ctrl)
+					storage := mockHandler.NewMockquoteStorage(ctrl)
+
+					pow.EXPECT().Guide(ctx, "192.168.1.1", "tour").Return("extended tour", nil)
+
+					return req, resp, pow, storage
+				},
+				wantErr: false,
+			},
+			{
+				name: "invalid tour",
+				mockFunc: func(ctrl *gomock.Controller) (server.Request, server.Response, *mockHandler.Mockpow, *mockHandler.MockquoteStorage) {
+					req := testRequest{
+						message: protocol.NewMessage(protocol.TypeGuide, "tour"),
+						addr:    "192.168.1.1",
+					}
+					resp := protocol.NewMessage(protocol.TypeGuide, "extended tour")
+
+					pow := mockHandler.NewMockpow(ctrl)
+					storage := mockHandler.NewMockquoteStorage(ctrl)
+
+					pow.EXPECT().Guide(ctx, "192.168.1.1", "tour").Return("", errors.New("tour error"))
+
+					return req, resp, pow, storage
+				},
+				wantErr: true,
+			},
+		}
+		for _, tt := range tests {
+			tt := tt
+			t.Run(tt.name, func(t *testing.T) {
+				t.Parallel()
+
+				req, resp, pow, storage := tt.mockFunc(ctrl)
+
+				h := handler.New(storage, pow)
+
+				got, err := h.Handle(ctx, req)
+
+				if tt.wantErr {
+					assert.Error(t, err)
+				} else {
+					assert.NoError(t, err)
+					assert.Equal(t, resp.ToString(), got.ToString())
+				}
+			})
+		}
+	})
+}
+
+func TestQuoteHandler_HandleErrorCodes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode protocol.ErrorCode
+	}{
+		{
+			name:     "rate limited",
+			err:      proofofwork.ErrTooManyOpenChallenges,
+			wantCode: protocol.CodeRateLimited,
+		},
+		{
+			name:     "expired",
+			err:      &puzzle.Error{Kind: puzzle.ErrExpired, Err: errors.New("stamp is expired")},
+			wantCode: protocol.CodeChallengeExpired,
+		},
+		{
+			name:     "not issued",
+			err:      cache.ErrNotFound,
+			wantCode: protocol.CodeChallengeExpired,
+		},
+		{
+			name:     "malformed challenge",
+			err:      &puzzle.Error{Kind: puzzle.ErrInvalidChallenge, Err: errors.New("invalid format")},
+			wantCode: protocol.CodeInvalidFormat,
+		},
+		{
+			name:     "spent stamp",
+			err:      proofofwork.ErrStampSpent,
+			wantCode: protocol.CodeInvalidSolution,
+		},
+		{
+			name: "internal",
+			err:  errors.New("redis: connection refused"),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			pow := mockHandler.NewMockpow(ctrl)
+			pow.EXPECT().Verify(ctx, "192.168.1.1", "stamp").Return(tt.err)
+
+			h := handler.New(mockHandler.NewMockquoteStorage(ctrl), pow)
+
+			_, err := h.Handle(ctx, testRequest{
+				message: protocol.NewMessage(protocol.TypeResource, "stamp"),
+				addr:    "192.168.1.1",
+			})
+
+			var protoErr *protocol.Error
+			if tt.wantCode == "" {
+				assert.ErrorIs(t, err, tt.err)
+				assert.False(t, errors.As(err, &protoErr))
+
+				return
+			}
+
+			assert.ErrorIs(t, err, protocol.NewError(tt.wantCode, ""))
+			assert.True(t, errors.As(err, &protoErr))
+			assert.Equal(t, tt.err.Error(), protoErr.Message)
+		})
+	}
 }
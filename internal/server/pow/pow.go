@@ -3,65 +3,405 @@ package pow
 import (
 	"context"
 	"errors"
+	"log"
 	"time"
 
 	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
 )
 
 //go:generate mockgen -source=./pow.go -destination=./mock/pow_mock.go
 
-const defaultZeroCount = 4
+const defaultBits = 16
 
 const defaultStampTTL = time.Minute * 2
 
+const defaultClockSkew = time.Second * 30
+
+// Cache key prefixes of issued challenges, open challenge counters of addresses and spent stamps.
+const (
+	challengeKeyPrefix = "challenge:"
+	openKeyPrefix      = "open:"
+	spentKeyPrefix     = "spent:"
+)
+
 type cache interface {
 	Add(ctx context.Context, key, value string, ttl time.Duration) error
+	AddNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
 	Get(ctx context.Context, key string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
 }
 
+var (
+	ErrParamsMismatch        = errors.New("challenge parameters differ from issued ones")
+	ErrResourceMismatch      = errors.New("challenge is issued for another resource")
+	ErrStampSpent            = errors.New("stamp is already spent")
+	ErrTooManyOpenChallenges = errors.New("too many open challenges")
+	ErrNotInteractive        = errors.New("issued puzzle is not interactive")
+	ErrClassicMultiStamps    = errors.New("classic stamps can't be combined into multi-stamps")
+)
+
 type PoW struct {
-	cache cache
+	cache             cache
+	signer            *signer
+	keys              []Key
+	issuer            puzzle.Issuer
+	bits              int
+	algorithm         hashcash.Algorithm
+	classic           bool
+	subStamps         int
+	maxOpenChallenges int64
+	maxStampAge       time.Duration
+	clockSkew         time.Duration
+	now               func() time.Time
+}
+
+// Option configures PoW created by New.
+type Option func(p *PoW)
+
+// WithPuzzle makes PoW issue challenges of the issuer instead of hashcash stamps,
+// e.g. puzzle.NewBalloonIssuer for memory-hard puzzles.
+func WithPuzzle(issuer puzzle.Issuer) Option {
+	return func(p *PoW) {
+		p.issuer = issuer
+	}
 }
 
-func New(cache cache) *PoW {
-	return &PoW{
-		cache: cache,
+// WithAlgorithm sets hash algorithm of issued stamps.
+func WithAlgorithm(alg hashcash.Algorithm) Option {
+	return func(p *PoW) {
+		p.algorithm = alg
 	}
 }
 
+// WithSolveTime sets difficulty of issued stamps, so client computing hashRate hashes per second
+// solves them in solveTime on average. Difficulty is rounded to bits, so actual time differs by a factor of up to sqrt(2).
+func WithSolveTime(solveTime time.Duration, hashRate float64) Option {
+	return func(p *PoW) {
+		p.bits = hashcash.BitsForSolveTime(solveTime, hashRate)
+	}
+}
+
+// WithClassicStamps makes PoW issue standard Hashcash version 1 stamps, which always use SHA-1,
+// so clients can solve them with classic hashcash libraries.
+func WithClassicStamps() Option {
+	return func(p *PoW) {
+		p.classic = true
+	}
+}
+
+// WithMultiStamps makes PoW issue multi-stamps of count hashcash sub-stamps, which must all be solved.
+// Total expected work stays the same as for a single stamp, but solving time varies less between challenges.
+// Classic stamps can't be combined into multi-stamps, New returns ErrClassicMultiStamps for them.
+func WithMultiStamps(count int) Option {
+	return func(p *PoW) {
+		p.subStamps = count
+	}
+}
+
+// WithStatelessKeys makes PoW sign challenges with HMAC instead of storing them in the cache,
+// so they are verified without any lookup. The first key signs new challenges,
+// all keys are accepted on verification to support key rotation. At least one key is required.
+func WithStatelessKeys(keys ...Key) Option {
+	return func(p *PoW) {
+		p.keys = append([]Key{}, keys...)
+	}
+}
+
+// WithMaxOpenChallenges limits the number of issued and not verified challenges of one address.
+// Counter of the address expires with its last issued challenge. Not applied in stateless mode.
+func WithMaxOpenChallenges(limit int) Option {
+	return func(p *PoW) {
+		p.maxOpenChallenges = int64(limit)
+	}
+}
+
+// WithMaxStampAge sets maximum age of the stamp date, accepted on verification. Defaults to challenge TTL.
+// Zero disables the check, so stamps are limited only by the cache TTL.
+func WithMaxStampAge(maxAge time.Duration) Option {
+	return func(p *PoW) {
+		p.maxStampAge = maxAge
+	}
+}
+
+// WithClockSkew sets tolerated difference between future stamp date and server time on verification.
+func WithClockSkew(skew time.Duration) Option {
+	return func(p *PoW) {
+		p.clockSkew = skew
+	}
+}
+
+// New returns PoW, which stores issued challenges in the cache.
+// Every challenge is identified by unique rand value of its stamp, so one client may have several of them.
+// In stateless mode, enabled by WithStatelessKeys, cache stores only spent stamps to prevent replays.
+// Hashcash stamps are issued, unless another puzzle is set by WithPuzzle.
+// Returns error, when stateless keys are invalid, e.g. ErrNoKeys, or options conflict, e.g. ErrClassicMultiStamps.
+func New(cache cache, opts ...Option) (*PoW, error) {
+	p := &PoW{
+		cache:       cache,
+		bits:        defaultBits,
+		algorithm:   hashcash.DefaultAlgorithm,
+		maxStampAge: defaultStampTTL,
+		clockSkew:   defaultClockSkew,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	if p.classic && p.subStamps > 1 {
+		return nil, ErrClassicMultiStamps
+	}
+
+	if p.keys != nil {
+		signer, err := newSigner(p.keys)
+		if err != nil {
+			return nil, err
+		}
+
+		p.signer = signer
+	}
+
+	switch {
+	case p.issuer != nil:
+	case p.subStamps > 1:
+		p.issuer = puzzle.NewMultiStampIssuer(p.bits, p.subStamps, p.stampOptions()...)
+	default:
+		p.issuer = puzzle.NewHashcashIssuer(p.bits, p.stampOptions()...)
+	}
+
+	return p, nil
+}
+
+// Puzzle returns name of the puzzle, which challenges are issued.
+func (p *PoW) Puzzle() string {
+	return p.issuer.Name()
+}
+
 // Generate generates Proof of Work string for a client with given resource.
 func (p *PoW) Generate(ctx context.Context, resource string) (string, error) {
-	stamp, err := hashcash.New(resource, defaultZeroCount, time.Now().Unix())
+	date := p.now().Unix()
+
+	// signature covers parameters of the challenge, so it's embedded into the challenge on issue
+	var signedRand string
+
+	if p.signer != nil {
+		var err error
+		if signedRand, err = p.signer.sign(p.params(resource, date)); err != nil {
+			return "", err
+		}
+	}
+
+	challenge, err := p.issuer.Issue(resource, date, signedRand)
 	if err != nil {
 		return "", err
 	}
 
-	if err := p.cache.Add(ctx, resource, stamp.GetRandValue(), defaultStampTTL); err != nil {
+	if err := p.issue(ctx, resource, challenge.GetRandValue()); err != nil {
 		return "", err
 	}
 
-	return stamp.ToString(), nil
+	return challenge.ToString(), nil
 }
 
 // Verify verifies Proof of Work string from a client by given resource and PoW algorithm.
 func (p *PoW) Verify(ctx context.Context, resource, data string) error {
-	stamp, err := hashcash.FromString(data)
+	challenge, err := p.parse(data)
 	if err != nil {
 		return err
 	}
 
-	randValue, err := p.cache.Get(ctx, resource)
+	// cheap checks of issued challenge go first, so solutions of unknown challenges aren't verified
+	if err := p.authenticate(ctx, resource, challenge); err != nil {
+		return err
+	}
+
+	// challenge is checked before it's consumed, so client may retry after invalid solution
+	if err := p.issuer.Verify(challenge, p.limits()); err != nil {
+		return err
+	}
+
+	return p.redeem(ctx, resource, challenge.GetRandValue(), challenge.GetDate())
+}
+
+// Guide moves interactive challenge, issued for the resource, one step forward and returns it,
+// e.g. serves a visit of the next guide of guided tour. Returns ErrNotInteractive for other puzzles.
+// Challenge is authenticated first, so server doesn't serve steps of challenges, which it didn't issue.
+func (p *PoW) Guide(ctx context.Context, resource, data string) (string, error) {
+	interactive, ok := p.issuer.(puzzle.Interactive)
+	if !ok {
+		return "", ErrNotInteractive
+	}
+
+	challenge, err := p.parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := p.authenticate(ctx, resource, challenge); err != nil {
+		return "", err
+	}
+
+	if err := interactive.Step(challenge); err != nil {
+		return "", err
+	}
+
+	return challenge.ToString(), nil
+}
+
+// parse parses challenge and checks, that client didn't change puzzle type or lower difficulty of issued challenge.
+func (p *PoW) parse(data string) (puzzle.Challenge, error) {
+	kind, challenge, err := puzzle.Parse(data)
 	if err != nil {
+		return nil, err
+	}
+
+	if kind.Name() != p.issuer.Name() || kind.Params(challenge) != p.issuer.Params() {
+		return nil, ErrParamsMismatch
+	}
+
+	return challenge, nil
+}
+
+// issue stores challenge with given ID, bound to the client address. Does nothing in stateless mode.
+func (p *PoW) issue(ctx context.Context, addr, id string) error {
+	if p.signer != nil {
+		return nil
+	}
+
+	if err := p.openChallenge(ctx, addr); err != nil {
 		return err
 	}
 
-	if randValue != stamp.GetRandValue() {
-		return errors.New("invalid rand value or challenge timeout exceeded")
+	if err := p.cache.Add(ctx, challengeKeyPrefix+id, addr, defaultStampTTL); err != nil {
+		p.closeChallenge(ctx, addr)
+
+		return err
 	}
 
-	if !stamp.Verify() {
-		return errors.New("invalid challenge stamp")
+	return nil
+}
+
+// authenticate checks, that challenge is issued for the resource without consuming it:
+// signature of stateless challenge or address, which stateful challenge is bound to in the cache.
+func (p *PoW) authenticate(ctx context.Context, resource string, challenge puzzle.Challenge) error {
+	if p.signer == nil {
+		addr, err := p.cache.Get(ctx, challengeKeyPrefix+challenge.GetRandValue())
+		if err != nil {
+			return err
+		}
+
+		if addr != resource {
+			return ErrResourceMismatch
+		}
+
+		return nil
+	}
+
+	if challenge.GetResource() != resource {
+		return ErrResourceMismatch
+	}
+
+	return p.signer.verify(challenge.GetRandValue(), p.params(challenge.GetResource(), challenge.GetDate()))
+}
+
+// redeem consumes authenticated challenge with given ID, so it can't be verified again.
+// Stateful challenge is removed from the cache, stateless one is marked spent until it expires.
+func (p *PoW) redeem(ctx context.Context, resource, id string, date int64) error {
+	if p.signer != nil {
+		// spent challenge is kept until it's rejected as expired anyway
+		ttl := p.stampMaxAge() - p.now().Sub(time.Unix(date, 0)) + time.Second
+
+		// signed rand value is unique for every challenge, so it identifies all solutions of the challenge
+		added, err := p.cache.AddNX(ctx, spentKeyPrefix+id, resource, ttl)
+		if err != nil {
+			return err
+		}
+
+		if !added {
+			return ErrStampSpent
+		}
+
+		return nil
+	}
+
+	// challenge may be consumed by concurrent verification after authentication, then it isn't found
+	if _, err := p.cache.GetDel(ctx, challengeKeyPrefix+id); err != nil {
+		return err
+	}
+
+	p.closeChallenge(ctx, resource)
+
+	return nil
+}
+
+// openChallenge counts new challenge of the address and checks the limit of open challenges.
+func (p *PoW) openChallenge(ctx context.Context, addr string) error {
+	if p.maxOpenChallenges <= 0 {
+		return nil
+	}
+
+	open, err := p.cache.Incr(ctx, openKeyPrefix+addr, defaultStampTTL)
+	if err != nil {
+		return err
+	}
+
+	if open > p.maxOpenChallenges {
+		p.closeChallenge(ctx, addr)
+
+		return ErrTooManyOpenChallenges
 	}
 
 	return nil
 }
+
+// closeChallenge releases open challenge of the address.
+func (p *PoW) closeChallenge(ctx context.Context, addr string) {
+	if p.maxOpenChallenges <= 0 {
+		return
+	}
+
+	if _, err := p.cache.Decr(ctx, openKeyPrefix+addr); err != nil {
+		log.Printf("error release open challenge of %s: %s", addr, err.Error())
+	}
+}
+
+// stampMaxAge returns max stamp age. Stateless challenges are always limited by age,
+// because spent stamps can't be stored forever.
+func (p *PoW) stampMaxAge() time.Duration {
+	if p.signer != nil && p.maxStampAge <= 0 {
+		return defaultStampTTL
+	}
+
+	return p.maxStampAge
+}
+
+func (p *PoW) limits() puzzle.Limits {
+	return puzzle.Limits{
+		MaxAge:    p.stampMaxAge(),
+		ClockSkew: p.clockSkew,
+		Now:       p.now,
+	}
+}
+
+// params returns parameters of the challenge with given resource and date, covered by signature.
+// Puzzle parameters are checked on parsing, so they're equal to the issuer ones.
+func (p *PoW) params(resource string, date int64) challengeParams {
+	return challengeParams{
+		resource: resource,
+		date:     date,
+		puzzle:   p.issuer.Name(),
+		params:   p.issuer.Params(),
+	}
+}
+
+func (p *PoW) stampOptions() []hashcash.Option {
+	if p.classic {
+		return []hashcash.Option{hashcash.WithClassicFormat("")}
+	}
+
+	return []hashcash.Option{hashcash.WithAlgorithm(p.algorithm)}
+}
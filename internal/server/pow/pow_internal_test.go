@@ -0,0 +1,46 @@
+package pow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+)
+
+func TestPoW_Stateless_Expired(t *testing.T) {
+	ctx := context.Background()
+
+	p, err := New(nil, WithStatelessKeys(Key{ID: 1, Secret: []byte("secret")}))
+	assert.NoError(t, err)
+
+	p.now = func() time.Time {
+		return time.Now().Add(-defaultStampTTL - time.Second)
+	}
+
+	challenge, err := p.Generate(ctx, "192.168.1.1")
+	assert.NoError(t, err)
+
+	stamp := parseStamp(t, challenge)
+	assert.NoError(t, hashcash.NewSolver(1).Solve(ctx, stamp))
+
+	p.now = time.Now
+
+	assert.ErrorIs(t, p.Verify(ctx, "192.168.1.1", stamp.ToString()), hashcash.ErrExpired)
+}
+
+// parseStamp parses hashcash stamp from the challenge, issued by PoW.
+func parseStamp(t *testing.T, challenge string) *hashcash.Stamp {
+	t.Helper()
+
+	_, parsed, err := puzzle.Parse(challenge)
+	assert.NoError(t, err)
+
+	c, ok := parsed.(puzzle.HashcashChallenge)
+	assert.True(t, ok)
+
+	return c.Stamp
+}
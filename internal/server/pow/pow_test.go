@@ -3,21 +3,52 @@ package pow_test
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/SergeySlonimsky/pow/internal/server/cache"
 	"github.com/SergeySlonimsky/pow/internal/server/pow"
 	mockPow "github.com/SergeySlonimsky/pow/internal/server/pow/mock"
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/guidedtour"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
 )
 
 const (
 	ipAddr    = "192.168.1.1"
-	stampData = "1:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0"
+	stampData = "3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28721"
 )
 
+var errCache = errors.New("cache error")
+
+// powCache is cache, which PoW is created with.
+type powCache interface {
+	Add(ctx context.Context, key, value string, ttl time.Duration) error
+	AddNX(ctx context.Context, key, value string, ttl time.Duration) (bool, error)
+	Get(ctx context.Context, key string) (string, error)
+	GetDel(ctx context.Context, key string) (string, error)
+	Incr(ctx context.Context, key string, ttl time.Duration) (int64, error)
+	Decr(ctx context.Context, key string) (int64, error)
+}
+
+// newPoW returns PoW created by pow.New and stops the test, when options are invalid.
+func newPoW(t *testing.T, c powCache, opts ...pow.Option) *pow.PoW {
+	t.Helper()
+
+	pw, err := pow.New(c, opts...)
+	require.NoError(t, err)
+
+	return pw
+}
+
 func TestPoW_Generate(t *testing.T) {
 	t.Parallel()
 
@@ -33,7 +64,7 @@ func TestPoW_Generate(t *testing.T) {
 			name: "valid",
 			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
 				cache := mockPow.NewMockcache(ctrl)
-				cache.EXPECT().Add(ctx, "192.168.1.1", gomock.Any(), time.Minute*2).Return(nil)
+				cache.EXPECT().Add(ctx, gomock.Any(), "192.168.1.1", time.Minute*2).Return(nil)
 
 				return cache
 			},
@@ -43,7 +74,7 @@ func TestPoW_Generate(t *testing.T) {
 			name: "cache error",
 			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
 				cache := mockPow.NewMockcache(ctrl)
-				cache.EXPECT().Add(ctx, "192.168.1.1", gomock.Any(), time.Minute*2).Return(errors.New("cache error"))
+				cache.EXPECT().Add(ctx, gomock.Any(), "192.168.1.1", time.Minute*2).Return(errors.New("cache error"))
 
 				return cache
 			},
@@ -51,24 +82,72 @@ func TestPoW_Generate(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
 			cache := tt.mockFunc(ctrl, ctx)
 
-			pw := pow.New(cache)
+			pw := newPoW(t, cache)
 			result, err := pw.Generate(ctx, ipAddr)
 
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
-				assert.Contains(t, ipAddr, result)
+				assert.Contains(t, result, ipAddr)
 			}
 		})
 	}
 }
 
+func TestPoW_Generate_WithAlgorithm(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Add(ctx, gomock.Any(), ipAddr, time.Minute*2).Return(nil)
+
+	pw := newPoW(t, cache, pow.WithAlgorithm(hashcash.SHA512))
+	result, err := pw.Generate(ctx, ipAddr)
+	assert.NoError(t, err)
+
+	stamp := parseStamp(t, result)
+	assert.Equal(t, hashcash.SHA512, stamp.GetAlgorithm())
+}
+
+func TestPoW_Generate_WithSolveTime(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Add(ctx, gomock.Any(), ipAddr, time.Minute*2).Return(nil)
+
+	pw := newPoW(t, cache, pow.WithSolveTime(time.Second, 1<<20))
+	result, err := pw.Generate(ctx, ipAddr)
+	assert.NoError(t, err)
+
+	stamp := parseStamp(t, result)
+	assert.Equal(t, 20, stamp.GetBits())
+	assert.Equal(t, time.Second, stamp.Cost().SolveTime(1<<20))
+}
+
+func TestPoW_Generate_WithClassicStamps(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Add(ctx, gomock.Any(), ipAddr, time.Minute*2).Return(nil)
+
+	pw := newPoW(t, cache, pow.WithClassicStamps())
+	result, err := pw.Generate(ctx, ipAddr)
+	assert.NoError(t, err)
+
+	stamp := parseStamp(t, result)
+	assert.True(t, stamp.IsClassic())
+	assert.Equal(t, 16, stamp.GetBits())
+}
+
 func TestPoW_Verify(t *testing.T) {
 	t.Parallel()
 
@@ -78,53 +157,669 @@ func TestPoW_Verify(t *testing.T) {
 	tests := []struct {
 		name     string
 		mockFunc func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache
-		wantErr  bool
+		wantErr  error
 	}{
 		{
 			name: "valid",
 			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
 				cache := mockPow.NewMockcache(ctrl)
-				cache.EXPECT().Get(ctx, "192.168.1.1").Return("FrZUho0yFjtWiiMonJTt55OFQ9k=", nil)
+				cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("192.168.1.1", nil)
+				cache.EXPECT().GetDel(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("192.168.1.1", nil)
 
 				return cache
 			},
-			wantErr: false,
+			wantErr: nil,
 		},
 		{
 			name: "cache error",
 			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
 				cache := mockPow.NewMockcache(ctrl)
-				cache.EXPECT().Get(ctx, "192.168.1.1").Return("", errors.New("cache error"))
+				cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("", errCache)
 
 				return cache
 			},
-			wantErr: true,
+			wantErr: errCache,
 		},
 		{
-			name: "rand number verify error",
+			name: "challenge of another address isn't consumed",
 			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
 				cache := mockPow.NewMockcache(ctrl)
-				cache.EXPECT().Get(ctx, "192.168.1.1").Return("invalid rand string", nil)
+				cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("192.168.1.2", nil)
 
 				return cache
 			},
-			wantErr: true,
+			wantErr: pow.ErrResourceMismatch,
+		},
+		{
+			name: "challenge consumed by concurrent verification",
+			mockFunc: func(ctrl *gomock.Controller, ctx context.Context) *mockPow.Mockcache {
+				cache := mockPow.NewMockcache(ctrl)
+				cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("192.168.1.1", nil)
+				cache.EXPECT().GetDel(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("", errCache)
+
+				return cache
+			},
+			wantErr: errCache,
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
 			cache := tt.mockFunc(ctrl, ctx)
 
-			pw := pow.New(cache)
+			// stamp fixture is issued long ago
+			pw := newPoW(t, cache, pow.WithMaxStampAge(0))
 			err := pw.Verify(ctx, ipAddr, stampData)
 
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestPoW_Stateless(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	oldKey := pow.Key{ID: 1, Secret: []byte("old secret")}
+	newKey := pow.Key{ID: 2, Secret: []byte("new secret")}
+
+	tests := []struct {
+		name     string
+		issuer   *pow.PoW
+		verifier *pow.PoW
+		resource string
+		tamper   func(data string) string
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: ipAddr,
+		},
+		{
+			name:     "valid classic stamp",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey), pow.WithClassicStamps()),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey), pow.WithClassicStamps()),
+			resource: ipAddr,
+		},
+		{
+			name:     "signed with rotated key",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(oldKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey, oldKey)),
+			resource: ipAddr,
+		},
+		{
+			name:     "signed with removed key",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(oldKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: ipAddr,
+			wantErr:  pow.ErrUnknownKey,
+		},
+		{
+			name:     "signed with another secret",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(pow.Key{ID: 2, Secret: []byte("forged")})),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: ipAddr,
+			wantErr:  pow.ErrInvalidSignature,
+		},
+		{
+			name:     "issued for another resource",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: "192.168.1.2",
+			wantErr:  pow.ErrResourceMismatch,
+		},
+		{
+			name:     "lowered difficulty",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: ipAddr,
+			tamper: func(data string) string {
+				return strings.Replace(data, ":16:", ":1:", 1)
+			},
+			wantErr: pow.ErrParamsMismatch,
+		},
+		{
+			name:     "raised difficulty",
+			issuer:   newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			verifier: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(newKey)),
+			resource: ipAddr,
+			tamper: func(data string) string {
+				return strings.Replace(data, ":16:", ":17:", 1)
+			},
+			wantErr: pow.ErrParamsMismatch,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			challenge, err := tt.issuer.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			if tt.tamper != nil {
+				challenge = tt.tamper(challenge)
+			}
+
+			stamp := parseStamp(t, challenge)
+			assert.NoError(t, hashcash.NewSolver(1).Solve(ctx, stamp))
+
+			err = tt.verifier.Verify(ctx, tt.resource, stamp.ToString())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 			}
 		})
 	}
 }
+
+func TestPoW_Verify_InvalidStampIsNotConsumed(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// no GetDel call is expected
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return(ipAddr, nil)
+
+	pw := newPoW(t, cache, pow.WithMaxStampAge(0))
+	err := pw.Verify(ctx, ipAddr, "3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28722")
+
+	assert.ErrorIs(t, err, hashcash.ErrInvalidHash)
+}
+
+func TestPoW_Verify_UnknownChallengeIsNotVerified(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return("", errCache)
+
+	// invalid solution isn't reported, as it isn't verified
+	pw := newPoW(t, cache, pow.WithMaxStampAge(0))
+	err := pw.Verify(ctx, ipAddr, "3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28722")
+
+	assert.ErrorIs(t, err, errCache)
+}
+
+func TestPoW_Verify_ParamsMismatch(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	guides, err := guidedtour.GenerateGuides(4)
+	assert.NoError(t, err)
+
+	modulus, err := vdf.GenerateModulus(256)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts []pow.Option
+		data string
+	}{
+		{
+			name: "lowered difficulty",
+			data: "3:sha256:15:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28721",
+		},
+		{
+			name: "another algorithm",
+			opts: []pow.Option{pow.WithAlgorithm(hashcash.SHA512)},
+			data: stampData,
+		},
+		{
+			name: "target instead of bits",
+			data: "3:sha256:0xffff:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28721",
+		},
+		{
+			name: "version 1 stamp",
+			data: "1:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+		},
+		{
+			name: "hashcash stamp instead of balloon puzzle",
+			opts: []pow.Option{pow.WithPuzzle(puzzle.NewBalloonIssuer(balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 1}))},
+			data: stampData,
+		},
+		{
+			name: "merkle puzzle with fewer proofs",
+			opts: []pow.Option{pow.WithPuzzle(puzzle.NewMerkleIssuer(merkle.Params{Height: 8, Proofs: 4}))},
+			data: "merkle:1:8:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+		},
+		{
+			name: "vdf puzzle in another group",
+			opts: []pow.Option{pow.WithPuzzle(puzzle.NewVDFIssuer(modulus, 1024))},
+			data: "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=://///////////////////////////////////////0M=::",
+		},
+		{
+			name: "hashcash stamp instead of guided tour",
+			opts: []pow.Option{pow.WithPuzzle(puzzle.NewTourIssuer(guides, 8))},
+			data: stampData,
+		},
+		{
+			name: "guided tour of another length",
+			opts: []pow.Option{pow.WithPuzzle(puzzle.NewTourIssuer(guides, 8))},
+			data: "tour:1:4:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:4:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		},
+		{
+			name: "balloon puzzle instead of hashcash stamp",
+			data: "balloon:1:16:1:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			// no cache call is expected
+			pw := newPoW(t, mockPow.NewMockcache(gomock.NewController(t)), append(tt.opts, pow.WithMaxStampAge(0))...)
+
+			assert.ErrorIs(t, pw.Verify(ctx, ipAddr, tt.data), pow.ErrParamsMismatch)
+		})
+	}
+}
+
+func TestPoW_Balloon(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	params := balloon.Params{SpaceCost: 64, TimeCost: 1, Bits: 4}
+
+	tests := []struct {
+		name string
+		opts []pow.Option
+	}{
+		{
+			name: "stateful",
+		},
+		{
+			name: "stateless",
+			opts: []pow.Option{pow.WithStatelessKeys(pow.Key{ID: 1, Secret: []byte("secret")})},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pw := newPoW(t, cache.NewMemoryCache(), append(tt.opts, pow.WithPuzzle(puzzle.NewBalloonIssuer(params)))...)
+
+			challenge, err := pw.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			puzzle, err := balloon.FromString(challenge)
+			assert.NoError(t, err)
+			assert.Equal(t, params, puzzle.GetParams())
+			assert.NoError(t, balloon.NewSolver(1).Solve(ctx, puzzle))
+
+			assert.NoError(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+			assert.Error(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+		})
+	}
+}
+
+func TestPoW_MultiStamps(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key := pow.Key{ID: 1, Secret: []byte("secret")}
+
+	tests := []struct {
+		name    string
+		issuer  *pow.PoW
+		opts    []pow.Option
+		wantErr error
+	}{
+		{
+			name: "stateful",
+		},
+		{
+			name:   "stateless",
+			issuer: newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(key), pow.WithMultiStamps(4)),
+			opts:   []pow.Option{pow.WithStatelessKeys(key)},
+		},
+		{
+			name:    "fewer sub-stamps",
+			issuer:  newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(key), pow.WithMultiStamps(2)),
+			opts:    []pow.Option{pow.WithStatelessKeys(key)},
+			wantErr: pow.ErrParamsMismatch,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pw := newPoW(t, cache.NewMemoryCache(), append(tt.opts, pow.WithMultiStamps(4))...)
+
+			issuer := tt.issuer
+			if issuer == nil {
+				issuer = pw
+			}
+
+			challenge, err := issuer.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			stamp, err := hashcash.MultiFromString(challenge)
+			assert.NoError(t, err)
+			assert.Equal(t, 16, stamp.GetBits())
+			assert.NoError(t, hashcash.NewSolver(1).SolveMulti(ctx, stamp))
+
+			err = pw.Verify(ctx, ipAddr, stamp.ToString())
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Error(t, pw.Verify(ctx, ipAddr, stamp.ToString()))
+		})
+	}
+}
+
+func TestPoW_Merkle(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	params := merkle.Params{Height: 8, Proofs: 4}
+
+	tests := []struct {
+		name string
+		opts []pow.Option
+	}{
+		{
+			name: "stateful",
+		},
+		{
+			name: "stateless",
+			opts: []pow.Option{pow.WithStatelessKeys(pow.Key{ID: 1, Secret: []byte("secret")})},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pw := newPoW(t, cache.NewMemoryCache(), append(tt.opts, pow.WithPuzzle(puzzle.NewMerkleIssuer(params)))...)
+
+			challenge, err := pw.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			puzzle, err := merkle.FromString(challenge)
+			assert.NoError(t, err)
+			assert.Equal(t, params, puzzle.GetParams())
+			assert.NoError(t, merkle.NewSolver(1).Solve(ctx, puzzle))
+
+			assert.NoError(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+			assert.Error(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+		})
+	}
+}
+
+func TestPoW_VDF(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	modulus, err := vdf.GenerateModulus(512)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name string
+		opts []pow.Option
+	}{
+		{
+			name: "stateful",
+		},
+		{
+			name: "stateless",
+			opts: []pow.Option{pow.WithStatelessKeys(pow.Key{ID: 1, Secret: []byte("secret")})},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pw := newPoW(t, cache.NewMemoryCache(), append(tt.opts, pow.WithPuzzle(puzzle.NewVDFIssuer(modulus, 1<<10)))...)
+
+			challenge, err := pw.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			puzzle, err := vdf.FromString(challenge)
+			assert.NoError(t, err)
+			assert.Equal(t, 1<<10, puzzle.GetIterations())
+			assert.NoError(t, vdf.Solve(ctx, puzzle))
+
+			assert.NoError(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+			assert.Error(t, pw.Verify(ctx, ipAddr, puzzle.ToString()))
+		})
+	}
+}
+
+func TestPoW_GuidedTour(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	guides, err := guidedtour.GenerateGuides(4)
+	assert.NoError(t, err)
+
+	// tour with the same parameters, which isn't issued by PoW
+	forged, err := puzzle.NewTourIssuer(guides, 8).Issue(ipAddr, time.Now().Unix(), "")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		opts       []pow.Option
+		wantForged error
+	}{
+		{
+			name:       "stateful",
+			wantForged: cache.ErrNotFound,
+		},
+		{
+			name:       "stateless",
+			opts:       []pow.Option{pow.WithStatelessKeys(pow.Key{ID: 1, Secret: []byte("secret")})},
+			wantForged: pow.ErrInvalidSignature,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			pw := newPoW(t, cache.NewMemoryCache(), append(tt.opts, pow.WithPuzzle(puzzle.NewTourIssuer(guides, 8)))...)
+
+			_, err := pw.Guide(ctx, ipAddr, forged.ToString())
+			assert.ErrorIs(t, err, tt.wantForged)
+
+			challenge, err := pw.Generate(ctx, ipAddr)
+			assert.NoError(t, err)
+
+			_, err = pw.Guide(ctx, "172.21.0.5", challenge)
+			assert.ErrorIs(t, err, pow.ErrResourceMismatch)
+			assert.ErrorIs(t, pw.Verify(ctx, ipAddr, challenge), guidedtour.ErrInvalidTour)
+
+			for i := 0; i < 8; i++ {
+				challenge, err = pw.Guide(ctx, ipAddr, challenge)
+				assert.NoError(t, err)
+			}
+
+			_, err = pw.Guide(ctx, ipAddr, challenge)
+			assert.ErrorIs(t, err, guidedtour.ErrTourCompleted)
+
+			assert.NoError(t, pw.Verify(ctx, ipAddr, challenge))
+			assert.Error(t, pw.Verify(ctx, ipAddr, challenge))
+		})
+	}
+}
+
+func TestPoW_Verify_Expired(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+
+	// no GetDel call is expected
+	cache := mockPow.NewMockcache(ctrl)
+	cache.EXPECT().Get(ctx, "challenge:FrZUho0yFjtWiiMonJTt55OFQ9k=").Return(ipAddr, nil)
+
+	pw := newPoW(t, cache)
+	err := pw.Verify(ctx, ipAddr, stampData)
+
+	assert.ErrorIs(t, err, hashcash.ErrExpired)
+}
+
+func TestPoW_Verify_Replay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	t.Run("stateful", func(t *testing.T) {
+		t.Parallel()
+
+		pw := newPoW(t, cache.NewMemoryCache())
+		assertSingleUse(ctx, t, pw)
+	})
+
+	t.Run("stateless", func(t *testing.T) {
+		t.Parallel()
+
+		pw := newPoW(t, cache.NewMemoryCache(), pow.WithStatelessKeys(pow.Key{ID: 1, Secret: []byte("secret")}))
+		assertSingleUse(ctx, t, pw)
+	})
+}
+
+func assertSingleUse(ctx context.Context, t *testing.T, pw *pow.PoW) {
+	t.Helper()
+
+	challenge, err := pw.Generate(ctx, ipAddr)
+	assert.NoError(t, err)
+
+	stamp := parseStamp(t, challenge)
+	assert.NoError(t, hashcash.NewSolver(1).Solve(ctx, stamp))
+
+	assert.NoError(t, pw.Verify(ctx, ipAddr, stamp.ToString()))
+	assert.Error(t, pw.Verify(ctx, ipAddr, stamp.ToString()))
+}
+
+func TestPoW_MultipleOpenChallenges(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	pw := newPoW(t, cache.NewMemoryCache(), pow.WithMaxOpenChallenges(2))
+
+	stamps := make([]*hashcash.Stamp, 0, 2)
+
+	for i := 0; i < 2; i++ {
+		challenge, err := pw.Generate(ctx, ipAddr)
+		assert.NoError(t, err)
+
+		stamp := parseStamp(t, challenge)
+		assert.NoError(t, hashcash.NewSolver(1).Solve(ctx, stamp))
+
+		stamps = append(stamps, stamp)
+	}
+
+	_, err := pw.Generate(ctx, ipAddr)
+	assert.ErrorIs(t, err, pow.ErrTooManyOpenChallenges)
+
+	_, err = pw.Generate(ctx, "192.168.1.2")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, pw.Verify(ctx, "192.168.1.2", stamps[0].ToString()), pow.ErrResourceMismatch)
+
+	assert.NoError(t, pw.Verify(ctx, ipAddr, stamps[1].ToString()))
+
+	_, err = pw.Generate(ctx, ipAddr)
+	assert.NoError(t, err)
+
+	// challenge isn't consumed by verification from another address
+	assert.NoError(t, pw.Verify(ctx, ipAddr, stamps[0].ToString()))
+}
+
+func TestParseKeys(t *testing.T) {
+	t.Parallel()
+
+	keys, err := pow.ParseKeys("2=bmV3IHNlY3JldA==, 1=b2xkIHNlY3JldA==")
+	assert.NoError(t, err)
+	assert.Equal(t, []pow.Key{
+		{ID: 2, Secret: []byte("new secret")},
+		{ID: 1, Secret: []byte("old secret")},
+	}, keys)
+
+	for _, invalid := range []string{"", "2", "256=bmV3", "a=bmV3", "2=!", "2=", "1=bmV3,1=b2xk"} {
+		_, err := pow.ParseKeys(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestNew_ClassicMultiStamps(t *testing.T) {
+	t.Parallel()
+
+	_, err := pow.New(cache.NewMemoryCache(), pow.WithClassicStamps(), pow.WithMultiStamps(2))
+	assert.ErrorIs(t, err, pow.ErrClassicMultiStamps)
+
+	// single sub-stamp is a plain stamp, so classic format is allowed
+	pw := newPoW(t, cache.NewMemoryCache(), pow.WithClassicStamps(), pow.WithMultiStamps(1))
+
+	challenge, err := pw.Generate(context.Background(), ipAddr)
+	assert.NoError(t, err)
+	assert.True(t, parseStamp(t, challenge).IsClassic())
+}
+
+func TestNew_InvalidStatelessKeys(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		keys    []pow.Key
+		wantErr error
+	}{
+		{
+			name:    "no keys",
+			keys:    nil,
+			wantErr: pow.ErrNoKeys,
+		},
+		{
+			name:    "empty secret",
+			keys:    []pow.Key{{ID: 1, Secret: []byte("secret")}, {ID: 2}},
+			wantErr: pow.ErrEmptySecret,
+		},
+		{
+			name:    "duplicate id",
+			keys:    []pow.Key{{ID: 1, Secret: []byte("new secret")}, {ID: 1, Secret: []byte("old secret")}},
+			wantErr: pow.ErrDuplicateKey,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := pow.New(cache.NewMemoryCache(), pow.WithStatelessKeys(tt.keys...))
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+// parseStamp parses hashcash stamp from the challenge, issued by PoW.
+func parseStamp(t *testing.T, challenge string) *hashcash.Stamp {
+	t.Helper()
+
+	_, parsed, err := puzzle.Parse(challenge)
+	assert.NoError(t, err)
+
+	c, ok := parsed.(puzzle.HashcashChallenge)
+	assert.True(t, ok)
+
+	return c.Stamp
+}
@@ -0,0 +1,179 @@
+package pow
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"strings"
+)
+
+// Signed rand value layout: key id, random nonce and HMAC-SHA256 of the challenge.
+const (
+	nonceSize     = 15
+	macSize       = sha256.Size
+	signedRandLen = 1 + nonceSize + macSize
+)
+
+var (
+	ErrUnknownKey       = errors.New("challenge is signed with unknown key")
+	ErrInvalidSignature = errors.New("invalid challenge signature")
+	ErrNoKeys           = errors.New("no keys to sign challenges")
+	ErrEmptySecret      = errors.New("key secret is empty")
+	ErrDuplicateKey     = errors.New("duplicate key id")
+)
+
+// Key is a server secret, used to sign stateless challenges.
+// ID is embedded into the challenge, so verifier knows, which key to use after rotation.
+type Key struct {
+	ID     uint8
+	Secret []byte
+}
+
+// ParseKeys parses comma separated list of keys formatted as "id=base64secret".
+// The first key is used for signing new challenges, others are accepted for verification only.
+// Every key must have unique ID.
+func ParseKeys(data string) ([]Key, error) {
+	items := strings.Split(data, ",")
+	keys := make([]Key, 0, len(items))
+
+	for _, item := range items {
+		parts := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.New("invalid key format")
+		}
+
+		id, err := strconv.ParseUint(parts[0], 10, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key id: %w", err)
+		}
+
+		secret, err := base64.StdEncoding.DecodeString(parts[1])
+		if err != nil || len(secret) == 0 {
+			return nil, fmt.Errorf("invalid secret of key %d", id)
+		}
+
+		key := Key{ID: uint8(id), Secret: secret}
+		if err := checkDuplicate(keys, key); err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// signer authenticates challenges with HMAC embedded into stamp rand value,
+// so challenges can be verified without any storage lookup.
+type signer struct {
+	active Key
+	keys   map[uint8][]byte
+}
+
+// newSigner returns signer, which signs challenges with the first key. Returns error, when there are no keys,
+// some key has empty secret or keys have the same ID.
+func newSigner(keys []Key) (*signer, error) {
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	s := &signer{
+		active: keys[0],
+		keys:   make(map[uint8][]byte, len(keys)),
+	}
+
+	for i, key := range keys {
+		if len(key.Secret) == 0 {
+			return nil, fmt.Errorf("%w: key %d", ErrEmptySecret, key.ID)
+		}
+
+		if err := checkDuplicate(keys[:i], key); err != nil {
+			return nil, err
+		}
+
+		s.keys[key.ID] = key.Secret
+	}
+
+	return s, nil
+}
+
+// checkDuplicate returns ErrDuplicateKey, when one of keys has ID of the key.
+func checkDuplicate(keys []Key, key Key) error {
+	for _, k := range keys {
+		if k.ID == key.ID {
+			return fmt.Errorf("%w: %d", ErrDuplicateKey, key.ID)
+		}
+	}
+
+	return nil
+}
+
+// sign returns rand value, which authenticates challenge with given parameters by the active key.
+func (s *signer) sign(params challengeParams) (string, error) {
+	signed := make([]byte, 1+nonceSize, signedRandLen)
+	signed[0] = s.active.ID
+
+	if _, err := rand.Read(signed[1:]); err != nil {
+		return "", err
+	}
+
+	signed = append(signed, s.mac(s.active.Secret, signed[:1+nonceSize], params)...)
+
+	return base64.StdEncoding.EncodeToString(signed), nil
+}
+
+// verify checks, that rand value of the challenge is signed by one of the keys for the challenge parameters.
+func (s *signer) verify(randValue string, params challengeParams) error {
+	signed, err := base64.StdEncoding.DecodeString(randValue)
+	if err != nil || len(signed) != signedRandLen {
+		return ErrInvalidSignature
+	}
+
+	secret, ok := s.keys[signed[0]]
+	if !ok {
+		return ErrUnknownKey
+	}
+
+	expected := s.mac(secret, signed[:1+nonceSize], params)
+	if !hmac.Equal(expected, signed[1+nonceSize:]) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// mac returns HMAC of key id and nonce header with challenge parameters.
+// Every field is length prefixed, so different parameters never produce the same message.
+func (s *signer) mac(secret, header []byte, params challengeParams) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(header)
+
+	writeField(mac, params.resource)
+	writeField(mac, strconv.FormatInt(params.date, 10))
+	writeField(mac, params.puzzle)
+	writeField(mac, params.params)
+
+	return mac.Sum(nil)
+}
+
+func writeField(h hash.Hash, field string) {
+	var size [4]byte
+
+	binary.BigEndian.PutUint32(size[:], uint32(len(field)))
+	h.Write(size[:])
+	h.Write([]byte(field))
+}
+
+// challengeParams are parameters of the challenge, covered by signature.
+type challengeParams struct {
+	resource string
+	date     int64
+	puzzle   string
+	params   string
+}
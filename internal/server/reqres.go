@@ -5,6 +5,8 @@ import (
 )
 
 type Response interface {
+	GetType() protocol.Type
+	GetBody() string
 	ToString() string
 }
 
@@ -4,11 +4,12 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
-	"strings"
 
 	"github.com/SergeySlonimsky/pow/pkg/protocol"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
 )
 
 type Handler interface {
@@ -16,13 +17,47 @@ type Handler interface {
 }
 
 type Server struct {
-	handler Handler
+	handler      Handler
+	maxFrameSize int
+	capabilities protocol.Capabilities
 }
 
-func New(handler Handler) *Server {
-	return &Server{
-		handler: handler,
+// Option configures Server created by New.
+type Option func(s *Server)
+
+// WithMaxFrameSize limits size of messages, read from and written to clients. protocol.DefaultMaxFrameSize is used by default.
+func WithMaxFrameSize(size int) Option {
+	return func(s *Server) {
+		s.maxFrameSize = size
+	}
+}
+
+// WithPuzzles sets names of puzzles, which server issues, so clients without their support are rejected on handshake.
+// Server issues hashcash stamps by default.
+func WithPuzzles(names ...string) Option {
+	return func(s *Server) {
+		s.capabilities.Puzzles = names
+	}
+}
+
+// New returns Server, which speaks protocol version of every client, negotiated by hello message
+// or detected by the first message of clients, which don't send hello.
+func New(handler Handler, opts ...Option) *Server {
+	s := &Server{
+		handler:      handler,
+		maxFrameSize: protocol.DefaultMaxFrameSize,
+		capabilities: protocol.Capabilities{
+			Versions:  []protocol.Version{protocol.V3, protocol.V2, protocol.V1},
+			Puzzles:   []string{puzzle.HashcashName},
+			Encodings: []string{protocol.EncodingText},
+		},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 func (s *Server) Run(ctx context.Context, port string) error {
@@ -52,14 +87,38 @@ func (s *Server) Run(ctx context.Context, port string) error {
 func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 	log.Printf("new connection from %s", conn.RemoteAddr().String())
 
-	for {
-		message, err := protocol.ParseFromReader(conn)
+	decoder := protocol.NewDecoder(conn, protocol.WithMaxFrameSize(s.maxFrameSize))
+
+	var encoder *protocol.Encoder
+
+	for first := true; ; first = false {
+		message, err := decoder.Decode()
+
+		if encoder == nil {
+			encoder = s.newEncoder(conn, decoder.Version())
+		}
+
+		// client closed connection, there is nobody to report to
+		if errors.Is(err, io.EOF) {
+			return
+		}
+
 		if err != nil {
-			writeData(conn, createErrorMessage(err))
+			writeData(encoder, createErrorMessage(err))
 
 			return
 		}
 
+		if first && message.GetType() == protocol.TypeHello {
+			if err := s.handshake(encoder, decoder, message); err != nil {
+				writeData(encoder, createErrorMessage(err))
+
+				return
+			}
+
+			continue
+		}
+
 		if message.GetType() == protocol.TypeErr {
 			if err = conn.Close(); err != nil {
 				log.Printf("error close connection: %s", err.Error())
@@ -70,7 +129,7 @@ func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 
 		addr, err := cleanClientAddr(conn.RemoteAddr().String())
 		if err != nil {
-			writeData(conn, createErrorMessage(err))
+			writeData(encoder, createErrorMessage(err).InReplyTo(message))
 
 			return
 		}
@@ -80,32 +139,97 @@ func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 			addr:    addr,
 		}
 
+		// requests are handled one by one, but responses echo request ID and headers,
+		// so clients may send requests without waiting for responses and match them by ID
 		resp, err := s.handler.Handle(ctx, req)
 		if err != nil {
-			writeData(conn, createErrorMessage(err))
+			writeData(encoder, createErrorMessage(err).InReplyTo(message))
 		} else {
-			writeData(conn, resp)
+			writeData(encoder, protocol.NewMessage(resp.GetType(), resp.GetBody()).InReplyTo(message))
 		}
 	}
 }
 
-func writeData(conn net.Conn, resp Response) {
-	restText := fmt.Sprintf("%s\n", resp.ToString())
+// handshake replies to client hello with capabilities, chosen from server ones,
+// and switches the connection to the chosen protocol version.
+func (s *Server) handshake(encoder *protocol.Encoder, decoder *protocol.Decoder, hello protocol.Message) error {
+	client, err := protocol.ParseHello(hello)
+	if err != nil {
+		return err
+	}
+
+	chosen, err := s.capabilities.Negotiate(client)
+	if err != nil {
+		return err
+	}
+
+	reply, err := protocol.NewHello(chosen)
+	if err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(reply); err != nil {
+		return err
+	}
+
+	encoder.SetVersion(chosen.Version())
+	decoder.SetVersion(chosen.Version())
+
+	return nil
+}
+
+// newEncoder returns encoder of the client protocol version. Version of client, which hasn't sent any message, is unknown,
+// so its errors are written in V1 text format.
+func (s *Server) newEncoder(conn net.Conn, version protocol.Version) *protocol.Encoder {
+	if version == protocol.VersionDetect {
+		version = protocol.V1
+	}
 
-	if _, err := conn.Write([]byte(restText)); err != nil {
+	return protocol.NewEncoder(conn, protocol.WithVersion(version), protocol.WithMaxFrameSize(s.maxFrameSize))
+}
+
+func writeData(encoder *protocol.Encoder, msg protocol.Message) {
+	err := encoder.Encode(msg)
+
+	// response, which can't be encoded, e.g. multiline quote in V1 format, is replaced with error, so client doesn't wait for it
+	if errors.Is(err, protocol.ErrInvalidFrame) || errors.Is(err, protocol.ErrFrameTooLarge) {
+		err = encoder.Encode(
+			protocol.NewError(protocol.CodeInternal, "response can't be encoded: "+err.Error()).ToMessage().InReplyTo(msg),
+		)
+	}
+
+	if err != nil {
 		log.Printf("error writing data: %s", err.Error())
 	}
 }
 
 func cleanClientAddr(addr string) (string, error) {
-	parts := strings.Split(addr, ":")
-	if len(parts) > 0 {
-		return parts[0], nil
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", errors.New("invalid client address")
 	}
 
-	return "", errors.New("invalid client address")
+	return host, nil
 }
 
+// createErrorMessage returns error message with code and description of protocol error or malformed client message.
+// Other errors are logged and reported as internal without details, so they don't leak server internals.
 func createErrorMessage(err error) protocol.Message {
-	return protocol.NewMessage(protocol.TypeErr, err.Error())
+	var protocolErr *protocol.Error
+	if errors.As(err, &protocolErr) {
+		return protocolErr.ToMessage()
+	}
+
+	switch {
+	case errors.Is(err, protocol.ErrFrameTooLarge),
+		errors.Is(err, protocol.ErrInvalidFrame),
+		errors.Is(err, protocol.ErrInvalidHello),
+		errors.Is(err, protocol.ErrIncompatible),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return protocol.NewError(protocol.CodeInvalidFormat, err.Error()).ToMessage()
+	default:
+		log.Printf("internal error: %s", err.Error())
+
+		return protocol.ErrInternal.ToMessage()
+	}
 }
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+)
+
+// handlerFunc is Handler, which calls the function.
+type handlerFunc func(ctx context.Context, req Request) (Response, error)
+
+func (f handlerFunc) Handle(ctx context.Context, req Request) (Response, error) {
+	return f(ctx, req)
+}
+
+// echoID replies to challenge request with challenge, which body contains ID of the request,
+// and to other requests with rate limit error.
+func echoID(_ context.Context, req Request) (Response, error) {
+	if req.GetMessage().GetType() != protocol.TypeChallenge {
+		return nil, protocol.ErrRateLimited
+	}
+
+	return protocol.NewMessage(protocol.TypeChallenge, "challenge "+req.GetMessage().GetID()), nil
+}
+
+// serve serves connections of the listener with the server until listener is closed.
+func serve(s *Server, listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handleConnection(context.Background(), conn)
+	}
+}
+
+func TestServer_PipelinedRequests(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer listener.Close()
+
+	go serve(New(handlerFunc(echoID)), listener)
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	defer conn.Close()
+
+	encoder := protocol.NewEncoder(conn, protocol.WithVersion(protocol.HelloVersion))
+	decoder := protocol.NewDecoder(conn, protocol.WithVersion(protocol.HelloVersion))
+
+	hello, err := protocol.NewHello(protocol.Capabilities{
+		Versions:  []protocol.Version{protocol.V3},
+		Puzzles:   []string{puzzle.HashcashName},
+		Encodings: []string{protocol.EncodingText},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, encoder.Encode(hello))
+
+	reply, err := decoder.Decode()
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	assert.Equal(t, protocol.TypeHello, reply.GetType())
+
+	encoder.SetVersion(protocol.V3)
+	decoder.SetVersion(protocol.V3)
+
+	requests := []protocol.Message{
+		protocol.NewMessage(protocol.TypeChallenge, "").WithID("1").WithHeader("trace", "a"),
+		protocol.NewMessage(protocol.TypeChallenge, "").WithID("2"),
+		protocol.NewMessage(protocol.TypeResource, "solution").WithID("3").WithHeader("trace", "c"),
+		protocol.NewMessage(protocol.TypeChallenge, "").WithID("4").WithHeader("trace", "d"),
+	}
+
+	// all requests are sent before any response is read
+	for _, request := range requests {
+		assert.NoError(t, encoder.Encode(request))
+	}
+
+	want := []protocol.Message{
+		protocol.NewMessage(protocol.TypeChallenge, "challenge 1").InReplyTo(requests[0]),
+		protocol.NewMessage(protocol.TypeChallenge, "challenge 2").InReplyTo(requests[1]),
+		protocol.ErrRateLimited.ToMessage().InReplyTo(requests[2]),
+		protocol.NewMessage(protocol.TypeChallenge, "challenge 4").InReplyTo(requests[3]),
+	}
+
+	for _, wantMsg := range want {
+		msg, err := decoder.Decode()
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Equal(t, wantMsg.GetType(), msg.GetType())
+		assert.Equal(t, wantMsg.GetBody(), msg.GetBody())
+		assert.Equal(t, wantMsg.GetID(), msg.GetID())
+		assert.Equal(t, wantMsg.GetHeaders(), msg.GetHeaders())
+	}
+}
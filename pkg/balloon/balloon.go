@@ -0,0 +1,80 @@
+package balloon
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+)
+
+// delta is the number of dependencies per block, recommended by the Balloon hashing paper.
+const delta = 3
+
+// BlockSize is the size of one memory block in bytes.
+const BlockSize = sha256.Size
+
+// Hash computes Balloon hash of the password with salt, built on SHA-256.
+// It fills spaceCost blocks of memory and mixes them timeCost rounds,
+// so computation needs spaceCost*BlockSize bytes and about spaceCost*timeCost*(delta+1) hashes.
+func Hash(password, salt []byte, spaceCost, timeCost int) []byte {
+	b := balloon{
+		hash:   sha256.New(),
+		blocks: make([][BlockSize]byte, spaceCost),
+	}
+
+	// expand input into buffer
+	b.mix(&b.blocks[0], password, salt)
+
+	for m := 1; m < spaceCost; m++ {
+		b.mix(&b.blocks[m], b.blocks[m-1][:])
+	}
+
+	var index [24]byte
+
+	// mix buffer contents
+	for t := 0; t < timeCost; t++ {
+		for m := 0; m < spaceCost; m++ {
+			prev := &b.blocks[(m+spaceCost-1)%spaceCost]
+			b.mix(&b.blocks[m], prev[:], b.blocks[m][:])
+
+			for i := 0; i < delta; i++ {
+				binary.LittleEndian.PutUint64(index[0:], uint64(t))
+				binary.LittleEndian.PutUint64(index[8:], uint64(m))
+				binary.LittleEndian.PutUint64(index[16:], uint64(i))
+
+				var other [BlockSize]byte
+
+				b.mix(&other, salt, index[:])
+				otherIndex := binary.LittleEndian.Uint64(other[:8]) % uint64(spaceCost)
+
+				b.mix(&b.blocks[m], b.blocks[m][:], b.blocks[otherIndex][:])
+			}
+		}
+	}
+
+	result := b.blocks[spaceCost-1]
+
+	return result[:]
+}
+
+type balloon struct {
+	hash    hash.Hash
+	counter uint64
+	blocks  [][BlockSize]byte
+}
+
+// mix writes hash of the counter and parts to dst and increments the counter.
+func (b *balloon) mix(dst *[BlockSize]byte, parts ...[]byte) {
+	var counter [8]byte
+
+	binary.LittleEndian.PutUint64(counter[:], b.counter)
+	b.counter++
+
+	b.hash.Reset()
+	b.hash.Write(counter[:])
+
+	for _, part := range parts {
+		b.hash.Write(part)
+	}
+
+	b.hash.Sum(dst[:0])
+}
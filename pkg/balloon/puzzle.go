@@ -0,0 +1,192 @@
+package balloon
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Prefix starts string representation of every puzzle, so it can't be confused with hashcash stamp.
+const Prefix = "balloon"
+
+// Version is the current puzzle format version.
+const Version = 1
+
+// Limits of puzzle parameters, which protect verifier from expensive puzzles.
+const (
+	MaxSpaceCost = 1 << 20 // MaxSpaceCost is 32 MiB of memory.
+	MaxTimeCost  = 64
+	MaxBits      = 32
+)
+
+// MaxPuzzleSize is maximum length of string representation of the puzzle. It fits any puzzle with fields
+// of allowed size, e.g. resource up to challenge.MaxResourceSize bytes, which may be percent-encoded.
+const MaxPuzzleSize = 1024
+
+// puzzleParts is the number of fields of string representation of the puzzle.
+const puzzleParts = 9
+
+var (
+	ErrInvalidParams   = errors.New("invalid puzzle parameters")
+	ErrInvalidFormat   = challenge.ErrInvalidFormat
+	ErrInvalidResource = challenge.ErrInvalidResource
+	ErrInvalidRand     = challenge.ErrInvalidRand
+)
+
+// DefaultParams need 128 KiB of memory per attempt and about 64 attempts to solve the puzzle.
+var DefaultParams = Params{SpaceCost: 4096, TimeCost: 1, Bits: 6}
+
+// Params are cost parameters of the puzzle.
+// Every attempt needs SpaceCost*BlockSize bytes of memory and mixes them TimeCost rounds,
+// and about 2^Bits attempts are needed to solve the puzzle.
+type Params struct {
+	SpaceCost int
+	TimeCost  int
+	Bits      int
+}
+
+// Validate checks, that parameters are within limits.
+func (p Params) Validate() error {
+	if p.SpaceCost < 1 || p.SpaceCost > MaxSpaceCost ||
+		p.TimeCost < 1 || p.TimeCost > MaxTimeCost ||
+		p.Bits < 0 || p.Bits > MaxBits {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+// Puzzle is a memory-hard proof of work: client searches nonce,
+// which makes Balloon hash of the puzzle have Bits leading zero bits.
+type Puzzle struct {
+	params   Params
+	date     int64
+	resource string
+	rand     string
+	nonce    int
+}
+
+// Option configures Puzzle created by New.
+type Option func(p *Puzzle)
+
+// WithRand sets rand field of the puzzle instead of generated random value,
+// e.g. to embed data, which allows issuer to authenticate the puzzle. Rand must be base64 string up to challenge.MaxRandSize.
+func WithRand(rand string) Option {
+	return func(p *Puzzle) {
+		p.rand = rand
+	}
+}
+
+// New returns Puzzle with given resource and parameters. Returns error when parameters are out of limits,
+// resource exceeds challenge.MaxResourceSize, rand is invalid or can't generate random base64 string.
+func New(resource string, params Params, date int64, opts ...Option) (*Puzzle, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := challenge.ValidateResource(resource); err != nil {
+		return nil, err
+	}
+
+	puzzle := &Puzzle{
+		params:   params,
+		date:     date,
+		resource: resource,
+	}
+
+	for _, opt := range opts {
+		opt(puzzle)
+	}
+
+	var err error
+	if puzzle.rand, err = challenge.Rand(puzzle.rand); err != nil {
+		return nil, err
+	}
+
+	return puzzle, nil
+}
+
+// IsPuzzle reports whether data looks like string representation of Balloon puzzle.
+func IsPuzzle(data string) bool {
+	return strings.HasPrefix(data, Prefix+":")
+}
+
+// FromString parses data string to Puzzle.
+// String should be formatted as "balloon:version:spaceCost:timeCost:bits:date:resource:rand:nonce",
+// where resource is escaped by challenge.EscapeResource. Every field is checked strictly against its format and size limits,
+// so ToString of parsed puzzle returns data.
+func FromString(data string) (*Puzzle, error) {
+	p := challenge.NewParser(data, Prefix, Version, puzzleParts, MaxPuzzleSize)
+
+	params := Params{
+		SpaceCost: p.Int("space cost"),
+		TimeCost:  p.Int("time cost"),
+		Bits:      p.Int("bits"),
+	}
+
+	puzzle := &Puzzle{
+		params:   params,
+		date:     p.Date(),
+		resource: p.Resource(),
+		rand:     p.Rand(),
+		nonce:    p.Int("nonce"),
+	}
+
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return puzzle, nil
+}
+
+// ToString returns string representation of the puzzle, separated with ":".
+func (p *Puzzle) ToString() string {
+	return p.prefix() + strconv.Itoa(p.nonce)
+}
+
+// Verify verifies puzzle solution. It costs one Balloon hash computation.
+func (p *Puzzle) Verify() bool {
+	return p.check(p.prefix(), p.nonce)
+}
+
+func (p *Puzzle) GetParams() Params {
+	return p.params
+}
+
+func (p *Puzzle) GetDate() int64 {
+	return p.date
+}
+
+func (p *Puzzle) GetResource() string {
+	return p.resource
+}
+
+func (p *Puzzle) GetRandValue() string {
+	return p.rand
+}
+
+func (p *Puzzle) GetNonce() int {
+	return p.nonce
+}
+
+// prefix returns string representation of the puzzle without nonce.
+func (p *Puzzle) prefix() string {
+	return fmt.Sprintf(
+		"%s:%d:%d:%d:%d:%d:%s:%s:",
+		Prefix, Version, p.params.SpaceCost, p.params.TimeCost, p.params.Bits, p.date, challenge.EscapeResource(p.resource), p.rand,
+	)
+}
+
+// check reports whether Balloon hash of the puzzle prefix with nonce, salted with rand, has enough leading zero bits.
+func (p *Puzzle) check(prefix string, nonce int) bool {
+	digest := Hash([]byte(prefix+strconv.Itoa(nonce)), []byte(p.rand), p.params.SpaceCost, p.params.TimeCost)
+
+	return challenge.HasLeadingZeroBits(digest, p.params.Bits)
+}
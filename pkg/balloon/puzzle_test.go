@@ -0,0 +1,182 @@
+package balloon_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	hash := balloon.Hash([]byte("password"), []byte("salt"), 16, 2)
+
+	assert.Len(t, hash, balloon.BlockSize)
+	assert.Equal(t, hash, balloon.Hash([]byte("password"), []byte("salt"), 16, 2))
+	assert.NotEqual(t, hash, balloon.Hash([]byte("password"), []byte("pepper"), 16, 2))
+	assert.NotEqual(t, hash, balloon.Hash([]byte("password"), []byte("salt"), 32, 2))
+	assert.NotEqual(t, hash, balloon.Hash([]byte("password"), []byte("salt"), 16, 3))
+}
+
+func TestPuzzle_Solve_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params balloon.Params
+	}{
+		{
+			name:   "small memory",
+			params: balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 4},
+		},
+		{
+			name:   "several rounds",
+			params: balloon.Params{SpaceCost: 64, TimeCost: 3, Bits: 4},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := balloon.New("172.21.0.4", tt.params, time.Now().Unix())
+			assert.NoError(t, err)
+
+			assert.NoError(t, balloon.NewSolver(2).Solve(context.Background(), puzzle))
+			assert.True(t, puzzle.Verify())
+
+			parsed, err := balloon.FromString(puzzle.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, puzzle.ToString(), parsed.ToString())
+			assert.Equal(t, tt.params, parsed.GetParams())
+			assert.NoError(t, parsed.Validate(challenge.WithMaxAge(time.Minute), challenge.WithClockSkew(0)))
+		})
+	}
+}
+
+func TestNew_IPv6(t *testing.T) {
+	t.Parallel()
+
+	puzzle, err := balloon.New("::1", balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 2}, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, balloon.NewSolver(1).Solve(context.Background(), puzzle))
+
+	parsed, err := balloon.FromString(puzzle.ToString())
+	assert.NoError(t, err)
+	assert.Equal(t, "::1", parsed.GetResource())
+	assert.True(t, parsed.Verify())
+}
+
+func TestNew_Invalid(t *testing.T) {
+	t.Parallel()
+
+	params := balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 2}
+
+	_, err := balloon.New("172.21.0.4", params, 1656370862, balloon.WithRand("a:b"))
+	assert.ErrorIs(t, err, balloon.ErrInvalidRand)
+
+	_, err = balloon.New(strings.Repeat("r", 257), params, 1656370862)
+	assert.ErrorIs(t, err, balloon.ErrInvalidResource)
+}
+
+func TestPuzzle_Validate_Expired(t *testing.T) {
+	puzzle, err := balloon.New("172.21.0.4", balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 1}, 1656370862)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, puzzle.Validate(challenge.WithMaxAge(time.Minute)), challenge.ErrExpired)
+}
+
+func TestFromString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: "balloon:1:1024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+		},
+		{
+			name: "escaped IPv6 resource",
+			data: "balloon:1:1024:1:8:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+		},
+		{
+			name:    "not escaped IPv6 resource",
+			data:    "balloon:1:1024:1:8:1656370862:::1:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "resource exceeds limit",
+			data:    "balloon:1:1024:1:8:1656370862:" + strings.Repeat("r", 257) + ":FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "puzzle exceeds limit",
+			data:    "balloon:1:1024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15" + strings.Repeat("5", 1024),
+			wantErr: true,
+		},
+		{
+			name:    "not canonical space cost",
+			data:    "balloon:1:01024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "signed date",
+			data:    "balloon:1:1024:1:8:+1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "rand is not base64",
+			data:    "balloon:1:1024:1:8:1656370862:172.21.0.4:rand?:15",
+			wantErr: true,
+		},
+		{
+			name:    "space cost exceeds limit",
+			data:    "balloon:1:1048577:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "zero time cost",
+			data:    "balloon:1:1024:0:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			data:    "balloon:2:1024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "hashcash stamp",
+			data:    "3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+		{
+			name:    "negative nonce",
+			data:    "balloon:1:1024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:-15",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := balloon.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.data, puzzle.ToString())
+			}
+		})
+	}
+}
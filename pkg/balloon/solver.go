@@ -0,0 +1,74 @@
+package balloon
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var ErrNonceExhausted = errors.New("nonce space exhausted")
+
+// Solver searches nonce of the puzzle in parallel, splitting nonce space between workers.
+// Memory usage is workers*SpaceCost*BlockSize bytes.
+type Solver struct {
+	workers int
+}
+
+// NewSolver returns Solver with given workers count. Uses runtime.NumCPU() workers when count is not positive.
+func NewSolver(workers int) *Solver {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Solver{
+		workers: workers,
+	}
+}
+
+// Solve finds nonce, which makes puzzle valid, and sets it to the puzzle.
+// All workers are stopped when the first solution is found or context is done,
+// and in the latter case ctx.Err() is returned and the nonce is left unset.
+func (s *Solver) Solve(ctx context.Context, puzzle *Puzzle) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	prefix := puzzle.prefix()
+	found := make(chan int, s.workers)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+
+		go func(start int) {
+			defer wg.Done()
+
+			// every attempt is expensive, so context is checked before each of them
+			for nonce := start; nonce >= 0 && ctx.Err() == nil; nonce += s.workers {
+				if puzzle.check(prefix, nonce) {
+					found <- nonce
+
+					cancel()
+
+					return
+				}
+			}
+		}(i)
+	}
+
+	wg.Wait()
+	close(found)
+
+	if nonce, ok := <-found; ok {
+		puzzle.nonce = nonce
+
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return ErrNonceExhausted
+}
@@ -0,0 +1,23 @@
+package balloon
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var ErrInvalidHash = errors.New("puzzle hash does not satisfy difficulty")
+
+// Validate checks puzzle date against options of the shared challenge checks, and then its hash.
+// Returns date error of the options or ErrInvalidHash.
+func (p *Puzzle) Validate(opts ...challenge.DateOption) error {
+	if err := challenge.ValidateDate(p.date, opts...); err != nil {
+		return err
+	}
+
+	if !p.Verify() {
+		return ErrInvalidHash
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package guidedtour
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+const secretSize = 32
+
+var (
+	ErrTourCompleted  = errors.New("tour is already completed")
+	ErrGuidesMismatch = errors.New("tour is issued for another set of guides")
+)
+
+// Guides are tour guides, simulated by secret keys of the issuer.
+// Every guide extends tour hash with HMAC of its key, so only issuer can verify the tour.
+type Guides struct {
+	secrets [][]byte
+}
+
+// NewGuides returns Guides with given secret keys, one per guide.
+func NewGuides(secrets ...[]byte) (*Guides, error) {
+	if len(secrets) < 1 || len(secrets) > MaxGuides {
+		return nil, ErrInvalidParams
+	}
+
+	for _, secret := range secrets {
+		if len(secret) == 0 {
+			return nil, ErrInvalidParams
+		}
+	}
+
+	return &Guides{secrets: secrets}, nil
+}
+
+// GenerateGuides returns count Guides with random secret keys.
+func GenerateGuides(count int) (*Guides, error) {
+	if count < 1 || count > MaxGuides {
+		return nil, ErrInvalidParams
+	}
+
+	secrets := make([][]byte, count)
+
+	for i := range secrets {
+		secrets[i] = make([]byte, secretSize)
+		if _, err := rand.Read(secrets[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Guides{secrets: secrets}, nil
+}
+
+// Count returns number of guides.
+func (g *Guides) Count() int {
+	return len(g.secrets)
+}
+
+// Visit makes the next guide of the tour extend its hash, moving the tour one step forward.
+// Returns ErrTourCompleted when all guides are visited already.
+func (g *Guides) Visit(tour *Tour) error {
+	if tour.guides != len(g.secrets) {
+		return ErrGuidesMismatch
+	}
+
+	if tour.Completed() {
+		return ErrTourCompleted
+	}
+
+	tour.hash = g.visit(tour.header(), tour.step, tour.hash)
+	tour.step++
+
+	return nil
+}
+
+// Verify reports whether tour is completed and its hash is produced by visiting all guides in order.
+// It recomputes the whole tour path, which costs Length HMAC computations.
+func (g *Guides) Verify(tour *Tour) bool {
+	if tour.guides != len(g.secrets) || !tour.Completed() {
+		return false
+	}
+
+	header := tour.header()
+	hash := tour.seed()
+
+	for step := 0; step < tour.length; step++ {
+		hash = g.visit(header, step, hash)
+	}
+
+	return hmac.Equal(hash, tour.hash)
+}
+
+// visit returns tour hash after visit of the guide, chosen by hash on the given step.
+func (g *Guides) visit(header string, step int, hash []byte) []byte {
+	var stepBytes [8]byte
+
+	binary.BigEndian.PutUint64(stepBytes[:], uint64(step))
+
+	mac := hmac.New(sha256.New, g.secrets[nextGuide(hash, len(g.secrets))])
+	mac.Write([]byte(header))
+	mac.Write(stepBytes[:])
+	mac.Write(hash)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,209 @@
+package guidedtour
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Prefix starts string representation of every tour, so it can't be confused with other puzzles.
+const Prefix = "tour"
+
+// Version is the current tour format version.
+const Version = 1
+
+// Limits of tour parameters, which protect guides and verifier from expensive tours.
+const (
+	MaxLength = 1024
+	MaxGuides = 256
+)
+
+// Defaults of tours, which cost client 16 sequential round trips to 8 guides.
+const (
+	DefaultLength = 16
+	DefaultGuides = 8
+)
+
+// MaxTourSize is maximum length of string representation of the tour. It fits any tour with fields
+// of allowed size, e.g. resource up to challenge.MaxResourceSize bytes, which may be percent-encoded.
+const MaxTourSize = 1024
+
+// tourParts is the number of fields of string representation of the tour.
+const tourParts = 9
+
+var (
+	ErrInvalidParams   = errors.New("invalid tour parameters")
+	ErrInvalidFormat   = challenge.ErrInvalidFormat
+	ErrInvalidResource = challenge.ErrInvalidResource
+	ErrInvalidRand     = challenge.ErrInvalidRand
+)
+
+// Tour is a latency-bound client puzzle: client visits Length guides in sequence,
+// and every guide extends tour hash with its secret key. The next guide is chosen by the current hash,
+// so the tour can't be parallelized or precomputed without guide keys.
+type Tour struct {
+	length   int
+	guides   int
+	date     int64
+	resource string
+	rand     string
+	step     int
+	hash     []byte
+}
+
+// Option configures Tour created by New.
+type Option func(t *Tour)
+
+// WithRand sets rand field of the tour instead of generated random value,
+// e.g. to embed data, which allows issuer to authenticate the tour. Rand must be base64 string up to challenge.MaxRandSize.
+func WithRand(rand string) Option {
+	return func(t *Tour) {
+		t.rand = rand
+	}
+}
+
+// New returns not started Tour with given resource, length and number of guides. Returns error when parameters are
+// out of limits, resource exceeds challenge.MaxResourceSize, rand is invalid or can't generate random base64 string.
+func New(resource string, length, guides int, date int64, opts ...Option) (*Tour, error) {
+	if err := ValidateParams(length, guides); err != nil {
+		return nil, err
+	}
+
+	if err := challenge.ValidateResource(resource); err != nil {
+		return nil, err
+	}
+
+	tour := &Tour{
+		length:   length,
+		guides:   guides,
+		date:     date,
+		resource: resource,
+	}
+
+	for _, opt := range opts {
+		opt(tour)
+	}
+
+	var err error
+	if tour.rand, err = challenge.Rand(tour.rand); err != nil {
+		return nil, err
+	}
+
+	tour.hash = tour.seed()
+
+	return tour, nil
+}
+
+// IsTour reports whether data looks like string representation of guided tour.
+func IsTour(data string) bool {
+	return strings.HasPrefix(data, Prefix+":")
+}
+
+// FromString parses data string to Tour.
+// String should be formatted as "tour:version:length:guides:date:resource:rand:step:hash",
+// where resource is escaped by challenge.EscapeResource. Every field is checked strictly against its format and size limits.
+func FromString(data string) (*Tour, error) {
+	p := challenge.NewParser(data, Prefix, Version, tourParts, MaxTourSize)
+
+	tour := &Tour{
+		length:   p.Int("length"),
+		guides:   p.Int("guides"),
+		date:     p.Date(),
+		resource: p.Resource(),
+		rand:     p.Rand(),
+		step:     p.Int("step"),
+	}
+
+	hash := p.Field()
+
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateParams(tour.length, tour.guides); err != nil {
+		return nil, err
+	}
+
+	if tour.step > tour.length {
+		return nil, &challenge.FieldError{Field: "step", Err: ErrInvalidFormat}
+	}
+
+	var err error
+	if tour.hash, err = base64.StdEncoding.DecodeString(hash); err != nil || len(tour.hash) != sha256.Size {
+		return nil, &challenge.FieldError{Field: "hash", Err: ErrInvalidFormat}
+	}
+
+	return tour, nil
+}
+
+// ToString returns string representation of the tour, separated with ":".
+func (t *Tour) ToString() string {
+	return fmt.Sprintf("%s%d:%s", t.header(), t.step, base64.StdEncoding.EncodeToString(t.hash))
+}
+
+// Completed reports whether all guides of the tour are visited.
+func (t *Tour) Completed() bool {
+	return t.step == t.length
+}
+
+// NextGuide returns index of the guide, which client has to visit next.
+func (t *Tour) NextGuide() int {
+	return nextGuide(t.hash, t.guides)
+}
+
+func (t *Tour) GetLength() int {
+	return t.length
+}
+
+func (t *Tour) GetGuides() int {
+	return t.guides
+}
+
+func (t *Tour) GetDate() int64 {
+	return t.date
+}
+
+func (t *Tour) GetResource() string {
+	return t.resource
+}
+
+func (t *Tour) GetRandValue() string {
+	return t.rand
+}
+
+func (t *Tour) GetStep() int {
+	return t.step
+}
+
+// header returns constant part of string representation of the tour, which all guides sign.
+func (t *Tour) header() string {
+	return fmt.Sprintf(
+		"%s:%d:%d:%d:%d:%s:%s:", Prefix, Version, t.length, t.guides, t.date, challenge.EscapeResource(t.resource), t.rand,
+	)
+}
+
+// seed returns tour hash before the first guide visit.
+func (t *Tour) seed() []byte {
+	seed := sha256.Sum256([]byte(t.header()))
+
+	return seed[:]
+}
+
+func nextGuide(hash []byte, guides int) int {
+	return int(binary.BigEndian.Uint64(hash) % uint64(guides))
+}
+
+// ValidateParams checks, that tour length and the number of guides are within limits,
+// e.g. to validate configuration of the issuer before any tour is created. Returns ErrInvalidParams otherwise.
+func ValidateParams(length, guides int) error {
+	if length < 1 || length > MaxLength || guides < 1 || guides > MaxGuides {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
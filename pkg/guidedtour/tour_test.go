@@ -0,0 +1,250 @@
+package guidedtour_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/guidedtour"
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+func TestGuides_Visit_Verify(t *testing.T) {
+	t.Parallel()
+
+	guides, err := guidedtour.NewGuides([]byte("guide 1"), []byte("guide 2"), []byte("guide 3"))
+	assert.NoError(t, err)
+
+	tour, err := guidedtour.New("172.21.0.4", 8, guides.Count(), time.Now().Unix())
+	assert.NoError(t, err)
+
+	for !tour.Completed() {
+		assert.False(t, guides.Verify(tour))
+		assert.Less(t, tour.NextGuide(), guides.Count())
+
+		// tour is sent to the next guide and back as a string
+		parsed, err := guidedtour.FromString(tour.ToString())
+		assert.NoError(t, err)
+		assert.NoError(t, guides.Visit(parsed))
+
+		tour = parsed
+	}
+
+	assert.Equal(t, 8, tour.GetStep())
+	assert.ErrorIs(t, guides.Visit(tour), guidedtour.ErrTourCompleted)
+	assert.NoError(t, guides.Validate(tour, challenge.WithMaxAge(time.Minute), challenge.WithClockSkew(0)))
+
+	other, err := guidedtour.NewGuides([]byte("guide 1"), []byte("guide 2"), []byte("forged"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, other.Validate(tour), guidedtour.ErrInvalidTour)
+
+	fewer, err := guidedtour.GenerateGuides(2)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, fewer.Visit(tour), guidedtour.ErrGuidesMismatch)
+	assert.ErrorIs(t, fewer.Validate(tour), guidedtour.ErrInvalidTour)
+}
+
+func TestGuides_Validate_SkippedGuide(t *testing.T) {
+	t.Parallel()
+
+	guides, err := guidedtour.GenerateGuides(4)
+	assert.NoError(t, err)
+
+	tour, err := guidedtour.New("172.21.0.4", 2, guides.Count(), time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, guides.Visit(tour))
+
+	// client claims the last step without visiting its guide
+	forged, err := guidedtour.FromString(fmt.Sprintf(
+		"tour:1:2:4:%d:172.21.0.4:%s:2:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=", tour.GetDate(), tour.GetRandValue(),
+	))
+	assert.NoError(t, err)
+	assert.True(t, forged.Completed())
+	assert.ErrorIs(t, guides.Validate(forged), guidedtour.ErrInvalidTour)
+}
+
+func TestGuides_Validate_Expired(t *testing.T) {
+	t.Parallel()
+
+	guides, err := guidedtour.GenerateGuides(1)
+	assert.NoError(t, err)
+
+	tour, err := guidedtour.New("172.21.0.4", 1, 1, 1656370862)
+	assert.NoError(t, err)
+	assert.NoError(t, guides.Visit(tour))
+
+	assert.ErrorIs(t, guides.Validate(tour, challenge.WithMaxAge(time.Minute)), challenge.ErrExpired)
+}
+
+func TestNew_IPv6(t *testing.T) {
+	t.Parallel()
+
+	guides, err := guidedtour.GenerateGuides(2)
+	assert.NoError(t, err)
+
+	tour, err := guidedtour.New("::1", 2, guides.Count(), time.Now().Unix())
+	assert.NoError(t, err)
+
+	for !tour.Completed() {
+		tour, err = guidedtour.FromString(tour.ToString())
+		assert.NoError(t, err)
+		assert.NoError(t, guides.Visit(tour))
+	}
+
+	assert.Equal(t, "::1", tour.GetResource())
+	assert.True(t, guides.Verify(tour))
+
+	_, err = guidedtour.New("::1", 2, guides.Count(), time.Now().Unix(), guidedtour.WithRand("a:b"))
+	assert.ErrorIs(t, err, guidedtour.ErrInvalidRand)
+}
+
+func TestValidateParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		length int
+		guides int
+		valid  bool
+	}{
+		{name: "defaults", length: guidedtour.DefaultLength, guides: guidedtour.DefaultGuides, valid: true},
+		{name: "limits", length: guidedtour.MaxLength, guides: guidedtour.MaxGuides, valid: true},
+		{name: "empty tour", length: 0, guides: 1},
+		{name: "too long tour", length: guidedtour.MaxLength + 1, guides: 1},
+		{name: "no guides", length: 1, guides: 0},
+		{name: "too many guides", length: 1, guides: guidedtour.MaxGuides + 1},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := guidedtour.ValidateParams(tt.length, tt.guides)
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, guidedtour.ErrInvalidParams)
+			}
+		})
+	}
+}
+
+func TestFromString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "valid",
+			data: "tour:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		},
+		{
+			name: "escaped IPv6 resource",
+			data: "tour:1:16:8:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		},
+		{
+			name:    "not escaped IPv6 resource",
+			data:    "tour:1:16:8:1656370862:::1:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "resource exceeds limit",
+			data:    "tour:1:16:8:1656370862:" + strings.Repeat("r", 257) + ":FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "not canonical step",
+			data:    "tour:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:03:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "rand is not base64",
+			data:    "tour:1:16:8:1656370862:172.21.0.4:rand?:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "length exceeds limit",
+			data:    "tour:1:1025:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "no guides",
+			data:    "tour:1:16:0:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "step beyond length",
+			data:    "tour:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:17:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "short hash",
+			data:    "tour:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAA",
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			data:    "tour:2:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:3:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			wantErr: true,
+		},
+		{
+			name:    "balloon puzzle",
+			data:    "balloon:1:1024:1:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:15",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tour, err := guidedtour.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.data, tour.ToString())
+			}
+		})
+	}
+}
+
+func TestFromString_InvalidProgress(t *testing.T) {
+	t.Parallel()
+
+	header := "tour:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k="
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "step exceeds length",
+			data: header + ":17:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		},
+		{
+			name: "short hash",
+			data: header + ":3:AAAA",
+		},
+		{
+			name: "hash is not base64",
+			data: header + ":3:hash?",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := guidedtour.FromString(tt.data)
+			assert.ErrorIs(t, err, guidedtour.ErrInvalidFormat)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package guidedtour
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var ErrInvalidTour = errors.New("tour is not completed or its path is invalid")
+
+// Validate checks tour date with the options, and then replays its path.
+// Returns date error of the options or ErrInvalidTour.
+func (g *Guides) Validate(tour *Tour, opts ...challenge.DateOption) error {
+	if err := challenge.ValidateDate(tour.date, opts...); err != nil {
+		return err
+	}
+
+	if !g.Verify(tour) {
+		return ErrInvalidTour
+	}
+
+	return nil
+}
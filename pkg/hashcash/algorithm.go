@@ -0,0 +1,91 @@
+package hashcash
+
+import (
+	"crypto/sha1" //nolint:gosec // kept for backward compatibility with version 1 stamps
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"sort"
+	"sync"
+)
+
+// Algorithm is an identifier of the hash function used to compute and verify stamp hash.
+type Algorithm string
+
+const (
+	SHA1      Algorithm = "sha1"       // SHA1 is used by version 1 stamps and kept for backward compatibility.
+	SHA256    Algorithm = "sha256"     // SHA256 is the default algorithm for new stamps.
+	SHA512    Algorithm = "sha512"     // SHA512 is SHA-512 with full 64 bytes digest.
+	SHA512256 Algorithm = "sha512_256" // SHA512256 is SHA-512/256, faster than SHA-256 on 64-bit platforms.
+)
+
+// DefaultAlgorithm is used by New when no algorithm is given.
+const DefaultAlgorithm = SHA256
+
+var ErrUnknownAlgorithm = errors.New("unknown hash algorithm")
+
+var registry = struct {
+	sync.RWMutex
+	algorithms map[Algorithm]func() hash.Hash
+}{
+	algorithms: map[Algorithm]func() hash.Hash{
+		SHA1:      sha1.New,
+		SHA256:    sha256.New,
+		SHA512:    sha512.New,
+		SHA512256: sha512.New512_256,
+	},
+}
+
+// Register makes hash algorithm available for stamps under the given name,
+// e.g. BLAKE2b from golang.org/x/crypto. Registering the same name twice replaces previous implementation.
+func Register(alg Algorithm, newHash func() hash.Hash) {
+	if alg == "" || newHash == nil {
+		panic("hashcash: invalid algorithm registration")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.algorithms[alg] = newHash
+}
+
+// Algorithms returns names of all registered algorithms in sorted order.
+func Algorithms() []Algorithm {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	algs := make([]Algorithm, 0, len(registry.algorithms))
+	for alg := range registry.algorithms {
+		algs = append(algs, alg)
+	}
+
+	sort.Slice(algs, func(i, j int) bool { return algs[i] < algs[j] })
+
+	return algs
+}
+
+// ParseAlgorithm returns registered algorithm with given name or error wrapping ErrUnknownAlgorithm,
+// e.g. to validate algorithm from configuration before issuing stamps.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	alg := Algorithm(name)
+	if _, err := lookupAlgorithm(alg); err != nil {
+		return "", fmt.Errorf("%w: %q, registered: %v", err, name, Algorithms())
+	}
+
+	return alg, nil
+}
+
+// lookupAlgorithm returns hash constructor of the algorithm or ErrUnknownAlgorithm.
+func lookupAlgorithm(alg Algorithm) (func() hash.Hash, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	newHash, ok := registry.algorithms[alg]
+	if !ok {
+		return nil, ErrUnknownAlgorithm
+	}
+
+	return newHash, nil
+}
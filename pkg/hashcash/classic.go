@@ -0,0 +1,207 @@
+package hashcash
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// classicVersion is the version of the standard Hashcash stamp format "ver:bits:date:resource:ext:rand:counter".
+const classicVersion = 1
+
+// classicRandBytesSize gives 16 base64 characters of rand without padding, as the classic hashcash tool does.
+const classicRandBytesSize = 12
+
+// classicAlphabet is used for counters of classic stamps.
+const classicAlphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/"
+
+// Date layouts of classic stamps: YYMMDD[hhmm[ss]] in UTC.
+const (
+	classicDateLayout        = "060102"
+	classicDateMinutesLayout = "0601021504"
+	classicDateSecondsLayout = "060102150405"
+)
+
+var ErrClassicFormat = errors.New("classic hashcash stamps support only sha1 and leading zero bits")
+
+// WithClassicFormat makes New mint standard Hashcash version 1 stamp with given extension field,
+// compatible with the classic hashcash tool and other libraries. Classic stamps always use SHA-1.
+func WithClassicFormat(ext string) Option {
+	return func(s *Stamp) {
+		s.version = classicVersion
+		s.classic = true
+		s.algorithm = SHA1
+		s.ext = ext
+	}
+}
+
+// IsClassic reports whether the stamp has standard Hashcash version 1 format.
+func (s *Stamp) IsClassic() bool {
+	return s.classic
+}
+
+// GetExtension returns extension field of classic stamp.
+func (s *Stamp) GetExtension() string {
+	return s.ext
+}
+
+// parseClassic parses fields of standard Hashcash version 1 stamp, following version field.
+// Date and counter are kept verbatim, because stamp hash covers their exact text.
+func parseClassic(parts []string) (*Stamp, error) {
+	bits, ok := challenge.ParseUint(parts[0], len(strconv.Itoa(MaxBits)))
+	if !ok || bits > MaxBits {
+		return nil, fieldError("difficulty", ErrInvalidDifficulty)
+	}
+
+	date, err := parseClassicDate(parts[1])
+	if err != nil {
+		return nil, fieldError("date", err)
+	}
+
+	resource, err := unescapeResource(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateExtension(parts[3]); err != nil {
+		return nil, fieldError("extension", err)
+	}
+
+	rand, err := parseRand(parts[4])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateClassicCounter(parts[5]); err != nil {
+		return nil, fieldError("counter", err)
+	}
+
+	counter, _ := decodeClassicCounter(parts[5])
+
+	return &Stamp{
+		version:     classicVersion,
+		classic:     true,
+		algorithm:   SHA1,
+		bits:        int(bits),
+		date:        date.Unix(),
+		dateText:    parts[1],
+		resource:    resource,
+		ext:         parts[3],
+		rand:        rand,
+		counter:     counter,
+		counterText: parts[5],
+	}, nil
+}
+
+// formatClassic returns string representation of the stamp in standard Hashcash version 1 format.
+func (s *Stamp) formatClassic() string {
+	return fmt.Sprintf(
+		"%d:%d:%s:%s:%s:%s:%s",
+		s.version, s.bits, s.classicDate(), challenge.EscapeResource(s.resource), s.ext, s.rand, s.classicCounter(),
+	)
+}
+
+// classicDate returns date field of classic stamp: verbatim text of parsed stamp or date with seconds.
+func (s *Stamp) classicDate() string {
+	if s.dateText != "" {
+		return s.dateText
+	}
+
+	return time.Unix(s.date, 0).UTC().Format(classicDateSecondsLayout)
+}
+
+// classicCounter returns counter field of classic stamp: verbatim text of parsed stamp or encoded counter.
+func (s *Stamp) classicCounter() string {
+	if s.counterText != "" {
+		return s.counterText
+	}
+
+	return encodeClassicCounter(s.counter)
+}
+
+func parseClassicDate(data string) (time.Time, error) {
+	var layout string
+
+	switch len(data) {
+	case len(classicDateLayout):
+		layout = classicDateLayout
+	case len(classicDateMinutesLayout):
+		layout = classicDateMinutesLayout
+	case len(classicDateSecondsLayout):
+		layout = classicDateSecondsLayout
+	default:
+		return time.Time{}, ErrInvalidDate
+	}
+
+	// layout of the date is chosen by its length, so every character must be a digit
+	if !challenge.IsDigits(data) {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	date, err := time.ParseInLocation(layout, data, time.UTC)
+	if err != nil {
+		return time.Time{}, ErrInvalidDate
+	}
+
+	return date, nil
+}
+
+// encodeClassicCounter encodes counter as base-64 number with classicAlphabet digits.
+func encodeClassicCounter(counter int) string {
+	return string(appendClassicCounter(nil, counter))
+}
+
+// appendClassicCounter appends counter encoded by encodeClassicCounter to dst.
+func appendClassicCounter(dst []byte, counter int) []byte {
+	if counter <= 0 {
+		return append(dst, classicAlphabet[0])
+	}
+
+	// 11 base-64 digits fit any int
+	var digits [11]byte
+
+	i := len(digits)
+	for ; counter > 0; counter /= len(classicAlphabet) {
+		i--
+		digits[i] = classicAlphabet[counter%len(classicAlphabet)]
+	}
+
+	return append(dst, digits[i:]...)
+}
+
+// validateClassicCounter checks, that counter of classic stamp consists of base64 characters and fits MaxCounterSize.
+// Other tools may encode counter in another way, so its value isn't checked.
+func validateClassicCounter(data string) error {
+	if data == "" || len(data) > MaxCounterSize {
+		return ErrInvalidCounter
+	}
+
+	for i := 0; i < len(data); i++ {
+		if !challenge.IsBase64Char(data[i]) {
+			return ErrInvalidCounter
+		}
+	}
+
+	return nil
+}
+
+// decodeClassicCounter decodes counter encoded by encodeClassicCounter.
+// Returns false for counters of other tools, which may use any text.
+func decodeClassicCounter(data string) (int, bool) {
+	counter := 0
+
+	for _, char := range data {
+		digit := strings.IndexRune(classicAlphabet, char)
+		if digit < 0 || counter > (int(^uint(0)>>1)-digit)/len(classicAlphabet) {
+			return 0, false
+		}
+
+		counter = counter*len(classicAlphabet) + digit
+	}
+
+	return counter, data != ""
+}
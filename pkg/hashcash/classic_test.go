@@ -0,0 +1,102 @@
+package hashcash_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestFromString_Classic(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		bits    int
+		date    time.Time
+		ext     string
+		valid   bool
+		wantErr bool
+	}{
+		{
+			name:  "stamp minted by hashcash tool",
+			data:  "1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			bits:  20,
+			date:  time.Date(2013, time.March, 3, 6, 0, 0, 0, time.UTC),
+			valid: true,
+		},
+		{
+			name:  "stamp with extension and short date",
+			data:  "1:20:130303:adam@cypherspace.org:x=1,2;y:McMybZIhxKXu57jd:ckvi",
+			bits:  20,
+			date:  time.Date(2013, time.March, 3, 0, 0, 0, 0, time.UTC),
+			ext:   "x=1,2;y",
+			valid: false,
+		},
+		{
+			name:    "invalid date",
+			data:    "1:20:13030306:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			wantErr: true,
+		},
+		{
+			name:    "invalid bits",
+			data:    "1:-20:130303:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, stamp.IsClassic())
+			assert.Equal(t, hashcash.SHA1, stamp.GetAlgorithm())
+			assert.Equal(t, tt.bits, stamp.GetBits())
+			assert.Equal(t, tt.ext, stamp.GetExtension())
+			assert.Equal(t, "adam@cypherspace.org", stamp.GetResource())
+			assert.Equal(t, tt.data, stamp.ToString())
+			assert.Equal(t, tt.valid, stamp.Verify())
+			assert.Equal(t, tt.date.Unix(), stamp.GetDate())
+		})
+	}
+}
+
+func TestNew_Classic(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2022, time.June, 28, 10, 20, 30, 0, time.UTC)
+
+	stamp, err := hashcash.New("172.21.0.4", 16, date.Unix(), hashcash.WithClassicFormat("a=b"))
+	assert.NoError(t, err)
+	assert.True(t, stamp.IsClassic())
+	assert.Regexp(t, `^1:16:220628102030:172\.21\.0\.4:a=b:[A-Za-z0-9+/]{16}:A$`, stamp.ToString())
+
+	assert.NoError(t, hashcash.NewSolver(2).Solve(context.Background(), stamp))
+	assert.True(t, stamp.Verify())
+
+	parsed, err := hashcash.FromString(stamp.ToString())
+	assert.NoError(t, err)
+	assert.Equal(t, stamp.ToString(), parsed.ToString())
+	assert.True(t, parsed.Verify())
+}
+
+func TestNew_Classic_InvalidAlgorithm(t *testing.T) {
+	_, err := hashcash.New(
+		"172.21.0.4", 16, time.Now().Unix(), hashcash.WithClassicFormat(""), hashcash.WithAlgorithm(hashcash.SHA256),
+	)
+
+	assert.ErrorIs(t, err, hashcash.ErrClassicFormat)
+}
@@ -0,0 +1,95 @@
+package hashcash
+
+import (
+	"math"
+	"math/big"
+	"time"
+)
+
+// Cost is the amount of work needed to solve stamps of some difficulty. Every hash attempt succeeds
+// independently with the same probability, so the number of attempts follows geometric distribution.
+type Cost struct {
+	probability float64
+}
+
+// BitsCost returns Cost of stamps with given leading zero bits difficulty.
+func BitsCost(bits int) Cost {
+	return Cost{probability: math.Ldexp(1, -bits)}
+}
+
+// TargetCost returns Cost of stamps, which digest of digestSize bytes should be below the target.
+func TargetCost(target *big.Int, digestSize int) Cost {
+	if target.Sign() <= 0 {
+		return Cost{}
+	}
+
+	space := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1), uint(digestSize*8)))
+	probability, _ := new(big.Float).Quo(new(big.Float).SetInt(target), space).Float64()
+
+	return Cost{probability: math.Min(probability, 1)}
+}
+
+// Cost returns Cost of the stamp difficulty. Stamp with unknown algorithm and target can't be solved.
+func (s *Stamp) Cost() Cost {
+	if s.target == nil {
+		return BitsCost(s.bits)
+	}
+
+	newHash, err := lookupAlgorithm(s.algorithm)
+	if err != nil {
+		return Cost{}
+	}
+
+	return TargetCost(s.target, newHash().Size())
+}
+
+// Probability returns probability, that a single hash attempt solves the stamp.
+func (c Cost) Probability() float64 {
+	return c.probability
+}
+
+// ExpectedAttempts returns mean number of hash attempts needed to solve the stamp.
+func (c Cost) ExpectedAttempts() float64 {
+	return 1 / c.probability
+}
+
+// Variance returns variance of the number of hash attempts needed to solve the stamp.
+// Standard deviation is close to the mean, so solving time of a single stamp varies a lot.
+func (c Cost) Variance() float64 {
+	return (1 - c.probability) / (c.probability * c.probability)
+}
+
+// SolveProbability returns probability to solve the stamp within given number of attempts.
+func (c Cost) SolveProbability(attempts uint64) float64 {
+	// 1 - (1-p)^k, computed without loss of precision for small p
+	return -math.Expm1(float64(attempts) * math.Log1p(-c.probability))
+}
+
+// AttemptsQuantile returns number of attempts, within which the stamp is solved with given probability,
+// e.g. 0.99 for attempts needed by 99% of clients.
+func (c Cost) AttemptsQuantile(probability float64) float64 {
+	switch {
+	case c.probability >= 1:
+		return 1
+	case c.probability <= 0:
+		return math.Inf(1)
+	}
+
+	return math.Max(math.Ceil(math.Log1p(-probability)/math.Log1p(-c.probability)), 1)
+}
+
+// SolveTime returns expected time to solve the stamp by client, which computes hashRate hashes per second.
+func (c Cost) SolveTime(hashRate float64) time.Duration {
+	return secondsToDuration(c.ExpectedAttempts() / hashRate)
+}
+
+// BitsForSolveTime returns leading zero bits difficulty, which expected solve time by client,
+// computing hashRate hashes per second, is closest to solveTime.
+func BitsForSolveTime(solveTime time.Duration, hashRate float64) int {
+	attempts := solveTime.Seconds() * hashRate
+	if attempts <= 1 {
+		return 0
+	}
+
+	return int(math.Min(math.Round(math.Log2(attempts)), MaxBits))
+}
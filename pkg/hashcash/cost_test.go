@@ -0,0 +1,135 @@
+package hashcash_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestBitsCost(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		bits        int
+		expected    float64
+		variance    float64
+		probability float64
+	}{
+		{
+			name:        "zero bits",
+			bits:        0,
+			expected:    1,
+			variance:    0,
+			probability: 1,
+		},
+		{
+			name:        "one bit",
+			bits:        1,
+			expected:    2,
+			variance:    2,
+			probability: 0.75,
+		},
+		{
+			name:        "20 bits",
+			bits:        20,
+			expected:    1 << 20,
+			variance:    (1<<20 - 1) * (1 << 20),
+			probability: 1 - math.Pow(1-1.0/(1<<20), 2),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cost := hashcash.BitsCost(tt.bits)
+			assert.Equal(t, tt.expected, cost.ExpectedAttempts())
+			assert.InDelta(t, tt.variance, cost.Variance(), tt.variance*1e-9)
+			assert.InDelta(t, tt.probability, cost.SolveProbability(2), 1e-12)
+		})
+	}
+}
+
+func TestCost_SolveProbability(t *testing.T) {
+	t.Parallel()
+
+	cost := hashcash.BitsCost(32)
+
+	assert.Equal(t, float64(0), cost.SolveProbability(0))
+	assert.InDelta(t, 1-1/math.E, cost.SolveProbability(1<<32), 1e-9)
+	assert.InDelta(t, 0.99, cost.SolveProbability(uint64(cost.AttemptsQuantile(0.99))), 1e-9)
+	assert.InDelta(t, math.Ln2*(1<<32), cost.AttemptsQuantile(0.5), 1)
+}
+
+func TestCost_SolveTime(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, 2*time.Second, hashcash.BitsCost(21).SolveTime(1<<20))
+	assert.Equal(t, time.Duration(math.MaxInt64), hashcash.BitsCost(hashcash.MaxBits).SolveTime(1))
+	assert.Equal(t, time.Duration(math.MaxInt64), hashcash.BitsCost(8).SolveTime(0))
+}
+
+func TestStamp_Cost(t *testing.T) {
+	t.Parallel()
+
+	// 3/4 of sha256 digests with 16 leading zero bits are below the target.
+	target := new(big.Int).Lsh(big.NewInt(3), 256-18)
+
+	stamp, err := hashcash.New("test", 0, time.Now().Unix(), hashcash.WithTarget(target))
+	assert.NoError(t, err)
+	assert.InDelta(t, float64(1<<16)*4/3, stamp.Cost().ExpectedAttempts(), 1e-6)
+
+	stamp, err = hashcash.New("test", 16, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.Equal(t, hashcash.BitsCost(16), stamp.Cost())
+}
+
+func TestBitsForSolveTime(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		solveTime time.Duration
+		hashRate  float64
+		bits      int
+	}{
+		{
+			name:      "exact power of two",
+			solveTime: time.Second,
+			hashRate:  1 << 20,
+			bits:      20,
+		},
+		{
+			name:      "rounded to closest bits",
+			solveTime: 3 * time.Second,
+			hashRate:  1 << 20,
+			bits:      22,
+		},
+		{
+			name:      "less than one attempt",
+			solveTime: time.Millisecond,
+			hashRate:  10,
+			bits:      0,
+		},
+		{
+			name:      "limited by max bits",
+			solveTime: time.Duration(math.MaxInt64),
+			hashRate:  math.MaxFloat64,
+			bits:      hashcash.MaxBits,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.bits, hashcash.BitsForSolveTime(tt.solveTime, tt.hashRate))
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package hashcash
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// targetPrefix marks difficulty field, which contains hex encoded target instead of leading zero bits.
+const targetPrefix = "0x"
+
+// bitsPerZeroChar is the number of bits in one leading hex '0' of version 1 and version 2 stamps.
+const bitsPerZeroChar = 4
+
+var ErrInvalidDifficulty = errors.New("invalid difficulty")
+
+// WithTarget makes stamp valid only when its digest, read as big-endian unsigned integer,
+// is below the target. Overrides leading zero bits difficulty.
+func WithTarget(target *big.Int) Option {
+	return func(s *Stamp) {
+		s.target = new(big.Int).Set(target)
+	}
+}
+
+// formatDifficulty returns difficulty field of the stamp for the given format version.
+func formatDifficulty(version, bits int, target *big.Int) string {
+	switch {
+	case version < Version:
+		return strconv.Itoa(bits / bitsPerZeroChar)
+	case target != nil:
+		return targetPrefix + target.Text(16)
+	default:
+		return strconv.Itoa(bits)
+	}
+}
+
+// parseDifficulty parses difficulty field of the stamp for the given format version.
+// Version 1 and version 2 stamps count leading hex '0' characters, which are converted to bits.
+// Only canonical representation is accepted: decimal without leading zeros or lowercase hex target.
+func parseDifficulty(version int, data string) (int, *big.Int, error) {
+	if version == Version && strings.HasPrefix(data, targetPrefix) {
+		hex := strings.TrimPrefix(data, targetPrefix)
+		if hex == "" || len(hex) > MaxBits/bitsPerZeroChar || hex[0] == '0' || strings.ToLower(hex) != hex {
+			return 0, nil, ErrInvalidDifficulty
+		}
+
+		target, ok := new(big.Int).SetString(hex, 16)
+		if !ok || target.Sign() <= 0 {
+			return 0, nil, ErrInvalidDifficulty
+		}
+
+		return 0, target, nil
+	}
+
+	value, ok := challenge.ParseUint(data, len(strconv.Itoa(MaxBits)))
+	if !ok {
+		return 0, nil, ErrInvalidDifficulty
+	}
+
+	if version < Version {
+		value *= bitsPerZeroChar
+	}
+
+	if value > MaxBits {
+		return 0, nil, ErrInvalidDifficulty
+	}
+
+	return int(value), nil, nil
+}
+
+// validateDifficulty checks, that difficulty of new stamp can be parsed back from its string representation.
+func validateDifficulty(bits int, target *big.Int) error {
+	if bits < 0 || bits > MaxBits || target != nil && (target.Sign() <= 0 || target.BitLen() > MaxBits) {
+		return ErrInvalidDifficulty
+	}
+
+	return nil
+}
+
+// checkDigest reports whether digest satisfies target, when it's set, or has at least bits leading zero bits.
+func checkDigest(digest []byte, bits int, target *big.Int) bool {
+	if target != nil {
+		return new(big.Int).SetBytes(digest).Cmp(target) < 0
+	}
+
+	return challenge.HasLeadingZeroBits(digest, bits)
+}
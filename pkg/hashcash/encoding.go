@@ -0,0 +1,300 @@
+package hashcash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// binaryVersion is the version of binary encoding of the stamp, written to its first byte.
+const binaryVersion = 1
+
+// Flags of binary encoding, which select encoding of the following fields.
+const (
+	flagClassic = 1 << iota // date and counter are written as text of classic stamp
+	flagTarget              // difficulty is written as target bytes instead of bits
+)
+
+var ErrInvalidEncoding = errors.New("invalid stamp encoding")
+
+// MarshalBinary encodes the stamp in compact binary form: numbers are written as unsigned varints,
+// strings are prefixed with their length, and resource is written without escaping.
+func (s *Stamp) MarshalBinary() ([]byte, error) {
+	var flags byte
+	if s.classic {
+		flags |= flagClassic
+	}
+
+	if s.target != nil {
+		flags |= flagTarget
+	}
+
+	data := []byte{binaryVersion, byte(s.version), flags}
+	data = appendString(data, string(s.algorithm))
+
+	if s.target != nil {
+		data = appendString(data, string(s.target.Bytes()))
+	} else {
+		data = appendUvarint(data, uint64(s.bits))
+	}
+
+	if s.classic {
+		data = appendString(data, s.classicDate())
+	} else {
+		data = appendUvarint(data, uint64(s.date))
+	}
+
+	data = appendString(data, s.resource)
+	data = appendString(data, s.ext)
+	data = appendString(data, s.rand)
+
+	if s.classic {
+		data = appendString(data, s.classicCounter())
+	} else {
+		data = appendUvarint(data, uint64(s.counter))
+	}
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes the stamp encoded by MarshalBinary.
+// Decoded fields are checked the same way as FromString does, and must be representable
+// in string format of the stamp version, so formatting never changes the stamp.
+func (s *Stamp) UnmarshalBinary(data []byte) error {
+	if len(data) > MaxStampSize {
+		return &ParseError{Err: ErrStampTooLarge}
+	}
+
+	stamp, err := decodeStamp(data)
+	if err != nil {
+		return err
+	}
+
+	if err := stamp.validateFormat(); err != nil {
+		return err
+	}
+
+	if err := stamp.validateFields(); err != nil {
+		return err
+	}
+
+	*s = *stamp
+
+	return nil
+}
+
+// decodeStamp reads fields of binary encoding without checking their values.
+func decodeStamp(data []byte) (*Stamp, error) {
+	d := decoder{reader: bytes.NewReader(data)}
+
+	if d.readByte() != binaryVersion {
+		return nil, ErrInvalidEncoding
+	}
+
+	stamp := &Stamp{
+		version: int(d.readByte()),
+	}
+
+	flags := d.readByte()
+	stamp.classic = flags&flagClassic != 0
+	stamp.algorithm = Algorithm(d.readString())
+
+	if flags&flagTarget != 0 {
+		raw := d.readString()
+		stamp.target = new(big.Int).SetBytes([]byte(raw))
+
+		// target with leading zero bytes isn't written back the same way
+		if len(stamp.target.Bytes()) != len(raw) {
+			return nil, fieldError("difficulty", ErrInvalidDifficulty)
+		}
+	} else {
+		stamp.bits = int(d.readUvarint(MaxBits))
+	}
+
+	if stamp.classic {
+		stamp.dateText = d.readString()
+	} else {
+		stamp.date = int64(d.readUvarint(1<<63 - 1))
+	}
+
+	stamp.resource = d.readString()
+	stamp.ext = d.readString()
+	stamp.rand = d.readString()
+
+	if stamp.classic {
+		stamp.counterText = d.readString()
+	} else {
+		stamp.counter = int(d.readUvarint(uint64(int(^uint(0) >> 1))))
+	}
+
+	if d.err != nil || d.reader.Len() > 0 {
+		return nil, ErrInvalidEncoding
+	}
+
+	return stamp, nil
+}
+
+// validateFormat checks, that version, algorithm and difficulty of decoded stamp
+// are written to string format of its version without changes.
+func (s *Stamp) validateFormat() error {
+	if _, err := parseVersion(strconv.Itoa(s.version)); err != nil {
+		return err
+	}
+
+	// fields, which aren't written to string representation of the stamp format, can't be set
+	if s.classic && s.version != classicVersion ||
+		!s.classic && s.ext != "" ||
+		s.target != nil && (s.classic || s.version != Version) {
+		return ErrInvalidEncoding
+	}
+
+	switch {
+	case s.classic && s.algorithm != SHA1:
+		return fieldError("algorithm", ErrClassicFormat)
+	case s.version == legacyVersion && s.algorithm != SHA1:
+		// legacy format has no algorithm field, it's always hashed with SHA-1
+		return fieldError("algorithm", ErrInvalidEncoding)
+	case !s.classic && s.version != legacyVersion:
+		if _, err := parseAlgorithm(string(s.algorithm)); err != nil {
+			return err
+		}
+	}
+
+	// version 1 and version 2 stamps count difficulty in leading hex '0' characters
+	if !s.classic && s.version < Version && s.bits%bitsPerZeroChar != 0 {
+		return fieldError("difficulty", ErrInvalidDifficulty)
+	}
+
+	if err := validateDifficulty(s.bits, s.target); err != nil {
+		return fieldError("difficulty", err)
+	}
+
+	return nil
+}
+
+// validateFields checks resource, rand and fields of classic stamp, kept as text.
+func (s *Stamp) validateFields() error {
+	if err := challenge.ValidateResource(s.resource); err != nil {
+		return fieldError("resource", err)
+	}
+
+	if _, err := parseRand(s.rand); err != nil {
+		return err
+	}
+
+	if !s.classic {
+		return nil
+	}
+
+	if err := validateExtension(s.ext); err != nil {
+		return fieldError("extension", err)
+	}
+
+	date, err := parseClassicDate(s.dateText)
+	if err != nil {
+		return fieldError("date", err)
+	}
+
+	if err := validateClassicCounter(s.counterText); err != nil {
+		return fieldError("counter", err)
+	}
+
+	s.date = date.Unix()
+	s.counter, _ = decodeClassicCounter(s.counterText)
+
+	return nil
+}
+
+// MarshalText returns string representation of the stamp.
+func (s *Stamp) MarshalText() ([]byte, error) {
+	return []byte(s.ToString()), nil
+}
+
+// UnmarshalText parses the stamp with FromString.
+func (s *Stamp) UnmarshalText(text []byte) error {
+	stamp, err := FromString(string(text))
+	if err != nil {
+		return err
+	}
+
+	*s = *stamp
+
+	return nil
+}
+
+// MarshalJSON encodes the stamp as JSON string with its string representation.
+func (s *Stamp) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.ToString())
+}
+
+// UnmarshalJSON decodes the stamp from JSON string, parsed with FromString.
+func (s *Stamp) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return err
+	}
+
+	return s.UnmarshalText([]byte(text))
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	return append(data, buf[:binary.PutUvarint(buf[:], value)]...)
+}
+
+func appendString(data []byte, value string) []byte {
+	return append(appendUvarint(data, uint64(len(value))), value...)
+}
+
+// decoder reads fields of binary encoding and keeps the first error, so fields are read without checks.
+type decoder struct {
+	reader *bytes.Reader
+	err    error
+}
+
+func (d *decoder) readByte() byte {
+	if d.err != nil {
+		return 0
+	}
+
+	var b byte
+
+	b, d.err = d.reader.ReadByte()
+
+	return b
+}
+
+func (d *decoder) readUvarint(limit uint64) uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	var value uint64
+
+	value, d.err = binary.ReadUvarint(d.reader)
+	if d.err == nil && value > limit {
+		d.err = ErrInvalidEncoding
+	}
+
+	return value
+}
+
+func (d *decoder) readString() string {
+	size := d.readUvarint(uint64(d.reader.Len()))
+	if d.err != nil {
+		return ""
+	}
+
+	value := make([]byte, size)
+
+	_, d.err = io.ReadFull(d.reader, value)
+
+	return string(value)
+}
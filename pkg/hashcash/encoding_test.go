@@ -0,0 +1,273 @@
+package hashcash_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestStamp_Encoding(t *testing.T) {
+	t.Parallel()
+
+	type message struct {
+		Stamp *hashcash.Stamp `json:"stamp"`
+	}
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "version 3 stamp with bits",
+			data: "3:sha256:16:1656370862:172.21.0.4:" + validRand + ":28721",
+		},
+		{
+			name: "version 3 stamp with target",
+			data: "3:sha512:0x" + strings.Repeat("f", 120) + ":1656370862:%3A%3A1:" + validRand + ":0",
+		},
+		{
+			name: "version 2 stamp",
+			data: "2:sha256:4:1656370862:172.21.0.4:" + validRand + ":2420",
+		},
+		{
+			name: "legacy version 1 stamp",
+			data: "1:4:1656370862:172.21.0.4:" + validRand + ":2420",
+		},
+		{
+			name: "classic stamp minted by hashcash tool",
+			data: "1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+		},
+		{
+			name: "classic stamp with extension",
+			data: "1:20:130303:adam@cypherspace.org:x=1,2;y:McMybZIhxKXu57jd:ckvi",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.FromString(tt.data)
+			assert.NoError(t, err)
+
+			binary, err := stamp.MarshalBinary()
+			assert.NoError(t, err)
+
+			var decoded hashcash.Stamp
+			assert.NoError(t, decoded.UnmarshalBinary(binary))
+			assert.Equal(t, stamp, &decoded)
+
+			text, err := stamp.MarshalText()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.data, string(text))
+
+			var parsed hashcash.Stamp
+			assert.NoError(t, parsed.UnmarshalText(text))
+			assert.Equal(t, stamp, &parsed)
+
+			encoded, err := json.Marshal(message{Stamp: stamp})
+			assert.NoError(t, err)
+			assert.JSONEq(t, `{"stamp":"`+tt.data+`"}`, string(encoded))
+
+			var decodedMessage message
+			assert.NoError(t, json.Unmarshal(encoded, &decodedMessage))
+			assert.Equal(t, stamp, decodedMessage.Stamp)
+		})
+	}
+}
+
+func TestStamp_Getters(t *testing.T) {
+	t.Parallel()
+
+	stamp, err := hashcash.FromString("3:sha512:0xff:1656370862:%3A%3A1:" + validRand + ":28721")
+	assert.NoError(t, err)
+
+	assert.Equal(t, hashcash.Version, stamp.GetVersion())
+	assert.Equal(t, hashcash.SHA512, stamp.GetAlgorithm())
+	assert.Equal(t, 0, stamp.GetBits())
+	assert.Equal(t, big.NewInt(0xff), stamp.GetTarget())
+	assert.Equal(t, int64(1656370862), stamp.GetDate())
+	assert.Equal(t, "::1", stamp.GetResource())
+	assert.Equal(t, validRand, stamp.GetRandValue())
+	assert.Equal(t, 28721, stamp.GetCounter())
+	assert.False(t, stamp.IsClassic())
+	assert.Empty(t, stamp.GetExtension())
+}
+
+func TestStamp_UnmarshalBinary_Invalid(t *testing.T) {
+	t.Parallel()
+
+	stamp, err := hashcash.FromString("3:sha256:16:1656370862:172.21.0.4:" + validRand + ":28721")
+	assert.NoError(t, err)
+
+	binary, err := stamp.MarshalBinary()
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "empty data",
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "unknown encoding version",
+			data:    append([]byte{2}, binary[1:]...),
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "truncated data",
+			data:    binary[:len(binary)-1],
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "trailing data",
+			data:    append(append([]byte{}, binary...), 0),
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "classic flag of version 3 stamp",
+			data:    append([]byte{1, 3, 1}, binary[3:]...),
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "unknown stamp version",
+			data:    append([]byte{1, 4}, binary[2:]...),
+			wantErr: hashcash.ErrInvalidVersion,
+		},
+		{
+			name:    "unknown algorithm",
+			data:    withAlgorithm(binary, "md5"),
+			wantErr: hashcash.ErrUnknownAlgorithm,
+		},
+		{
+			name:    "version 2 stamp with bits not multiple of 4",
+			data:    withBits(t, "2:sha256:4:1656370862:172.21.0.4:"+validRand+":2420", 17),
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "legacy version 1 stamp with bits not multiple of 4",
+			data:    withBits(t, "1:4:1656370862:172.21.0.4:"+validRand+":2420", 15),
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "classic stamp with sha256",
+			data:    withAlgorithm(marshal(t, "1:20:130303:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi"), "sha256"),
+			wantErr: hashcash.ErrClassicFormat,
+		},
+		{
+			name:    "legacy version 1 stamp with sha256",
+			data:    withAlgorithm(marshal(t, "1:4:1656370862:172.21.0.4:"+validRand+":2420"), "sha256"),
+			wantErr: hashcash.ErrInvalidEncoding,
+		},
+		{
+			name:    "target with leading zero byte",
+			data:    withTarget(binary, []byte{0, 1}),
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "zero target",
+			data:    withTarget(binary, nil),
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var decoded hashcash.Stamp
+			assert.ErrorIs(t, decoded.UnmarshalBinary(tt.data), tt.wantErr)
+		})
+	}
+}
+
+// Offsets of binary encoding fields: encoding version, stamp version, flags and algorithm with length prefix.
+const (
+	flagsOffset     = 2
+	algorithmOffset = 3
+)
+
+func marshal(t *testing.T, data string) []byte {
+	t.Helper()
+
+	stamp, err := hashcash.FromString(data)
+	assert.NoError(t, err)
+
+	binary, err := stamp.MarshalBinary()
+	assert.NoError(t, err)
+
+	return binary
+}
+
+// withAlgorithm replaces algorithm of binary encoded stamp.
+func withAlgorithm(binary []byte, alg string) []byte {
+	end := algorithmOffset + 1 + int(binary[algorithmOffset])
+
+	data := append([]byte{}, binary[:algorithmOffset]...)
+	data = append(data, byte(len(alg)))
+	data = append(data, alg...)
+
+	return append(data, binary[end:]...)
+}
+
+// withBits replaces difficulty of binary encoded stamp with bits, which are written as one byte varint.
+func withBits(t *testing.T, data string, bits byte) []byte {
+	t.Helper()
+
+	binary := marshal(t, data)
+	offset := algorithmOffset + 1 + int(binary[algorithmOffset])
+	binary[offset] = bits
+
+	return binary
+}
+
+// withTarget replaces one byte bits difficulty of binary encoded version 3 stamp with target bytes.
+func withTarget(binary []byte, target []byte) []byte {
+	offset := algorithmOffset + 1 + int(binary[algorithmOffset])
+
+	data := append([]byte{}, binary[:offset]...)
+	data[flagsOffset] |= 2
+	data = append(data, byte(len(target)))
+	data = append(data, target...)
+
+	return append(data, binary[offset+1:]...)
+}
+
+func FuzzStamp_UnmarshalBinary(f *testing.F) {
+	stamp, err := hashcash.FromString("1:20:130303:adam@cypherspace.org:x=1,2;y:McMybZIhxKXu57jd:ckvi")
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	binary, err := stamp.MarshalBinary()
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	f.Add(binary)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var decoded hashcash.Stamp
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			return
+		}
+
+		encoded, err := decoded.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var again hashcash.Stamp
+		if err := again.UnmarshalBinary(encoded); err != nil || again.ToString() != decoded.ToString() {
+			t.Fatalf("stamp %q is not decoded back: %v", decoded.ToString(), err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package hashcash
+
+import (
+	"bytes"
+	"encoding"
+	"hash"
+	"strconv"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// counterBufSize fits decimal and classic representation of any int counter.
+const counterBufSize = 24
+
+// hasher checks stamp counters without allocations. Hash state after the constant stamp prefix
+// is computed once and restored for every attempt, so only counter is hashed.
+// It's not safe for concurrent use, every solver worker has its own hasher.
+type hasher struct {
+	hash    hash.Hash
+	state   []byte
+	prefix  []byte
+	classic bool
+	counter []byte
+	digest  []byte
+	bits    int
+	target  []byte
+}
+
+func newHasher(stamp *Stamp) (*hasher, error) {
+	newHash, err := lookupAlgorithm(stamp.algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate := *stamp
+	candidate.counterText = ""
+
+	data := candidate.ToString()
+
+	h := &hasher{
+		hash:    newHash(),
+		prefix:  []byte(data[:strings.LastIndexByte(data, ':')+1]),
+		classic: stamp.classic,
+		counter: make([]byte, 0, counterBufSize),
+		bits:    stamp.bits,
+	}
+
+	h.digest = make([]byte, 0, h.hash.Size())
+	h.target = targetBytes(stamp, h.hash.Size())
+
+	if stamp.target != nil && h.target == nil {
+		// every digest is below the target and has at least 0 leading zero bits
+		h.bits = 0
+	}
+
+	// hashes, which can't save their state, rehash the prefix on every attempt
+	if marshaler, ok := h.hash.(encoding.BinaryMarshaler); ok {
+		if _, ok := h.hash.(encoding.BinaryUnmarshaler); ok {
+			h.hash.Write(h.prefix)
+
+			if h.state, err = marshaler.MarshalBinary(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return h, nil
+}
+
+// check reports whether stamp with the given counter satisfies difficulty.
+func (h *hasher) check(counter int) bool {
+	if h.state != nil {
+		// state is produced by the same hash, so it can't be rejected
+		_ = h.hash.(encoding.BinaryUnmarshaler).UnmarshalBinary(h.state)
+	} else {
+		h.hash.Reset()
+		h.hash.Write(h.prefix)
+	}
+
+	if h.classic {
+		h.counter = appendClassicCounter(h.counter[:0], counter)
+	} else {
+		h.counter = strconv.AppendInt(h.counter[:0], int64(counter), 10)
+	}
+
+	h.hash.Write(h.counter)
+	h.digest = h.hash.Sum(h.digest[:0])
+
+	if h.target != nil {
+		return bytes.Compare(h.digest, h.target) < 0
+	}
+
+	return challenge.HasLeadingZeroBits(h.digest, h.bits)
+}
+
+// targetBytes returns stamp target as big-endian bytes of digest size.
+// Returns nil for bits difficulty and for target, which exceeds any digest.
+func targetBytes(stamp *Stamp, size int) []byte {
+	if stamp.target == nil || stamp.target.BitLen() > size*8 {
+		return nil
+	}
+
+	return stamp.target.FillBytes(make([]byte, size))
+}
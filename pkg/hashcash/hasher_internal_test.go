@@ -0,0 +1,111 @@
+package hashcash
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestStamps(t testing.TB) map[string]*Stamp {
+	t.Helper()
+
+	now := time.Now().Unix()
+	stamps := make(map[string]*Stamp)
+
+	for _, alg := range Algorithms() {
+		stamp, err := New("172.21.0.4", 8, now, WithAlgorithm(alg))
+		assert.NoError(t, err)
+
+		stamps[string(alg)] = stamp
+	}
+
+	classic, err := New("172.21.0.4", 8, now, WithClassicFormat("ext"))
+	assert.NoError(t, err)
+
+	stamps["classic"] = classic
+
+	target, err := New("172.21.0.4", 0, now, WithTarget(new(big.Int).Lsh(big.NewInt(3), 256-10)))
+	assert.NoError(t, err)
+
+	stamps["target"] = target
+
+	legacy, err := FromString("1:2:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0")
+	assert.NoError(t, err)
+
+	stamps["legacy"] = legacy
+
+	return stamps
+}
+
+func Test_hasher_check(t *testing.T) {
+	t.Parallel()
+
+	for name, stamp := range newTestStamps(t) {
+		stamp := stamp
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			h, err := newHasher(stamp)
+			assert.NoError(t, err)
+
+			candidate := *stamp
+
+			for counter := 0; counter < 2000; counter++ {
+				candidate.setCounter(counter)
+
+				assert.Equal(t, candidate.Verify(), h.check(counter), "counter %d", counter)
+			}
+		})
+	}
+}
+
+func Test_hasher_check_Allocs(t *testing.T) {
+	for name, stamp := range newTestStamps(t) {
+		h, err := newHasher(stamp)
+		assert.NoError(t, err)
+
+		counter := 1 << 40
+
+		allocs := testing.AllocsPerRun(1000, func() {
+			counter++
+			h.check(counter)
+		})
+
+		assert.Zero(t, allocs, name)
+	}
+}
+
+// BenchmarkAttempt_Naive measures attempt of the formatting solver, which was replaced by hasher.
+func BenchmarkAttempt_Naive(b *testing.B) {
+	for name, stamp := range newTestStamps(b) {
+		candidate := *stamp
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				candidate.setCounter(i)
+
+				digest, _ := generateHash(candidate.algorithm, candidate.ToString())
+				checkDigest(digest, candidate.bits, candidate.target)
+			}
+		})
+	}
+}
+
+func BenchmarkAttempt_Hasher(b *testing.B) {
+	for name, stamp := range newTestStamps(b) {
+		h, err := newHasher(stamp)
+		assert.NoError(b, err)
+
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				h.check(i)
+			}
+		})
+	}
+}
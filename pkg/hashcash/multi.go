@@ -0,0 +1,297 @@
+package hashcash
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// MultiPrefix starts string representation of multi-stamps.
+const MultiPrefix = "multistamp"
+
+// MultiVersion is the current multi-stamp format version.
+const MultiVersion = 1
+
+// MaxSubStamps is maximum number of sub-stamps in multi-stamp, so its string representation fits MaxStampSize.
+const MaxSubStamps = 64
+
+// multiParts is the number of fields of multi-stamp string representation.
+const multiParts = 9
+
+var ErrInvalidCount = errors.New("invalid sub-stamps count")
+
+// MultiStamp is a set of independent sub-stamps, which must all be solved. Difficulty of every sub-stamp
+// is lowered, so total expected work of count sub-stamps equals expected work of a single stamp with given bits,
+// but solving time varies much less: standard deviation of total attempts is divided by sqrt of count.
+// Sub-stamps are version 3 stamps with target difficulty, which share algorithm, date and resource of the multi-stamp.
+// Single sub-stamp keeps bits difficulty, so multi-stamp of one sub-stamp is the same as a plain stamp.
+// Rand of every sub-stamp is derived from the multi-stamp rand and sub-stamp index, so only counters are transferred.
+type MultiStamp struct {
+	algorithm Algorithm
+	bits      int
+	date      int64
+	resource  string
+	rand      string
+	stamps    []*Stamp
+}
+
+// NewMulti returns MultiStamp of count sub-stamps with total difficulty of bits leading zero bits.
+// Accepts WithAlgorithm and WithRand options, classic format and target difficulty are not supported.
+// Returns ErrInvalidCount, when count is out of [1, MaxSubStamps] range or count above 1 isn't below 2^bits.
+func NewMulti(resource string, bits, count int, date int64, opts ...Option) (*MultiStamp, error) {
+	base, err := New(resource, bits, date, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if base.classic || base.target != nil {
+		return nil, ErrInvalidFormat
+	}
+
+	return newMulti(base.algorithm, bits, count, date, resource, base.rand)
+}
+
+// MultiFromString parses multi-stamp, formatted as "multistamp:1:algorithm:bits:count:date:resource:rand:counters",
+// where counters are comma separated counters of all sub-stamps.
+// Returns *ParseError, which wraps one of the package errors, when multi-stamp is malformed.
+func MultiFromString(data string) (*MultiStamp, error) {
+	if len(data) > MaxStampSize {
+		return nil, &ParseError{Err: ErrStampTooLarge}
+	}
+
+	parts := strings.SplitN(data, ":", multiParts+1)
+	if len(parts) != multiParts || parts[0] != MultiPrefix {
+		return nil, &ParseError{Err: ErrInvalidFormat}
+	}
+
+	if parts[1] != strconv.Itoa(MultiVersion) {
+		return nil, fieldError("version", ErrInvalidVersion)
+	}
+
+	algorithm, err := parseAlgorithm(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	bits, ok := challenge.ParseUint(parts[3], len(strconv.Itoa(MaxBits)))
+	if !ok {
+		return nil, fieldError("difficulty", ErrInvalidDifficulty)
+	}
+
+	count, ok := challenge.ParseUint(parts[4], len(strconv.Itoa(MaxSubStamps)))
+	if !ok {
+		return nil, fieldError("count", ErrInvalidCount)
+	}
+
+	date, err := parseDate(parts[5])
+	if err != nil {
+		return nil, err
+	}
+
+	resource, err := unescapeResource(parts[6])
+	if err != nil {
+		return nil, err
+	}
+
+	rand, err := parseRand(parts[7])
+	if err != nil {
+		return nil, err
+	}
+
+	stamp, err := newMulti(algorithm, int(bits), int(count), date, resource, rand)
+	if errors.Is(err, ErrInvalidCount) {
+		return nil, fieldError("count", err)
+	}
+
+	if err != nil {
+		return nil, fieldError("difficulty", err)
+	}
+
+	counters := strings.Split(parts[8], ",")
+	if len(counters) != len(stamp.stamps) {
+		return nil, fieldError("counter", ErrInvalidCounter)
+	}
+
+	for i, data := range counters {
+		counter, err := parseCounter(data)
+		if err != nil {
+			return nil, err
+		}
+
+		stamp.stamps[i].setCounter(counter)
+	}
+
+	return stamp, nil
+}
+
+// IsMulti reports whether data looks like multi-stamp rather than a single stamp.
+func IsMulti(data string) bool {
+	return strings.HasPrefix(data, MultiPrefix+":")
+}
+
+// Verify reports whether all sub-stamps are solved.
+func (m *MultiStamp) Verify() bool {
+	for _, stamp := range m.stamps {
+		if !stamp.Verify() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Validate verifies multi-stamp date with given options and hashes of all sub-stamps.
+// Returns ErrExpired, ErrFutureDate or ErrInvalidHash when multi-stamp is not valid.
+func (m *MultiStamp) Validate(opts ...VerifyOption) error {
+	if err := challenge.ValidateDate(m.date, opts...); err != nil {
+		return err
+	}
+
+	if !m.Verify() {
+		return ErrInvalidHash
+	}
+
+	return nil
+}
+
+// ToString returns string representation of the multi-stamp, separated with ":".
+func (m *MultiStamp) ToString() string {
+	counters := make([]string, len(m.stamps))
+	for i, stamp := range m.stamps {
+		counters[i] = strconv.Itoa(stamp.counter)
+	}
+
+	return fmt.Sprintf(
+		"%s:%d:%s:%d:%d:%d:%s:%s:%s", MultiPrefix, MultiVersion, m.algorithm, m.bits, len(m.stamps), m.date,
+		challenge.EscapeResource(m.resource), m.rand, strings.Join(counters, ","),
+	)
+}
+
+// Cost returns Cost of a single sub-stamp. Expected attempts of the whole multi-stamp are count times greater.
+func (m *MultiStamp) Cost() Cost {
+	return m.stamps[0].Cost()
+}
+
+// GetAlgorithm returns hash algorithm of sub-stamps.
+func (m *MultiStamp) GetAlgorithm() Algorithm {
+	return m.algorithm
+}
+
+// GetBits returns total difficulty of the multi-stamp in leading zero bits.
+func (m *MultiStamp) GetBits() int {
+	return m.bits
+}
+
+// GetCount returns number of sub-stamps.
+func (m *MultiStamp) GetCount() int {
+	return len(m.stamps)
+}
+
+// GetDate returns unix time of the multi-stamp creation.
+func (m *MultiStamp) GetDate() int64 {
+	return m.date
+}
+
+// GetResource returns resource of the multi-stamp without escaping.
+func (m *MultiStamp) GetResource() string {
+	return m.resource
+}
+
+// GetRandValue returns base64 rand field of the multi-stamp, which sub-stamp rand values are derived from.
+func (m *MultiStamp) GetRandValue() string {
+	return m.rand
+}
+
+// GetStamps returns sub-stamps. Solving them solves the multi-stamp.
+func (m *MultiStamp) GetStamps() []*Stamp {
+	return m.stamps
+}
+
+// SolveMulti solves all sub-stamps of the multi-stamp one by one, every one of them on all workers.
+// Progress is reported for every sub-stamp separately.
+func (s *Solver) SolveMulti(ctx context.Context, stamp *MultiStamp) error {
+	for _, sub := range stamp.stamps {
+		if err := s.Solve(ctx, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newMulti(algorithm Algorithm, bits, count int, date int64, resource, rand string) (*MultiStamp, error) {
+	target, err := subTarget(algorithm, bits, count)
+	if err != nil {
+		return nil, err
+	}
+
+	// target overrides bits of sub-stamps, single sub-stamp has no target
+	opts, subBits := []Option{WithAlgorithm(algorithm)}, bits
+	if target != nil {
+		opts, subBits = append(opts, WithTarget(target)), 0
+	}
+
+	stamp := &MultiStamp{
+		algorithm: algorithm,
+		bits:      bits,
+		date:      date,
+		resource:  resource,
+		rand:      rand,
+		stamps:    make([]*Stamp, count),
+	}
+
+	for i := range stamp.stamps {
+		sub, err := New(resource, subBits, date, append(opts, WithRand(subRand(rand, i)))...)
+		if err != nil {
+			return nil, err
+		}
+
+		stamp.stamps[i] = sub
+	}
+
+	return stamp, nil
+}
+
+// subTarget returns target of sub-stamps, which makes single sub-stamp count times easier than bits difficulty:
+// probability of digest below count*2^(digestBits-bits) is count/2^bits. Returns nil target for single sub-stamp,
+// which has bits difficulty of the multi-stamp.
+func subTarget(algorithm Algorithm, bits, count int) (*big.Int, error) {
+	newHash, err := lookupAlgorithm(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	digestBits := newHash().Size() * 8
+	if bits < 0 || bits > digestBits {
+		return nil, ErrInvalidDifficulty
+	}
+
+	if count < 1 || count > MaxSubStamps {
+		return nil, ErrInvalidCount
+	}
+
+	if count == 1 {
+		return nil, nil
+	}
+
+	// count must be below 2^bits, so the target fits digest
+	if big.NewInt(int64(count)).BitLen() > bits {
+		return nil, ErrInvalidCount
+	}
+
+	return new(big.Int).Lsh(big.NewInt(int64(count)), uint(digestBits-bits)), nil
+}
+
+// subRand derives rand of i-th sub-stamp from multi-stamp rand.
+func subRand(rand string, i int) string {
+	digest := sha256.Sum256([]byte(rand + ":" + strconv.Itoa(i)))
+
+	return base64.StdEncoding.EncodeToString(digest[:])
+}
@@ -0,0 +1,234 @@
+package hashcash_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestMultiStamp_Solve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		resource  string
+		bits      int
+		count     int
+		algorithm hashcash.Algorithm
+	}{
+		{
+			name:      "power of two count",
+			resource:  "172.21.0.4",
+			bits:      12,
+			count:     4,
+			algorithm: hashcash.SHA256,
+		},
+		{
+			name:      "single sub-stamp",
+			resource:  "172.21.0.4",
+			bits:      8,
+			count:     1,
+			algorithm: hashcash.SHA1,
+		},
+		{
+			name:      "single sub-stamp without difficulty",
+			resource:  "172.21.0.4",
+			bits:      0,
+			count:     1,
+			algorithm: hashcash.SHA256,
+		},
+		{
+			name:      "odd count and ipv6 resource",
+			resource:  "::1",
+			bits:      10,
+			count:     3,
+			algorithm: hashcash.SHA512,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.NewMulti(tt.resource, tt.bits, tt.count, time.Now().Unix(), hashcash.WithAlgorithm(tt.algorithm))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.count, stamp.GetCount())
+
+			// total expected work of sub-stamps equals work of a single stamp
+			assert.InDelta(t, hashcash.BitsCost(tt.bits).ExpectedAttempts(), float64(tt.count)*stamp.Cost().ExpectedAttempts(), 1e-6)
+
+			assert.NoError(t, hashcash.NewSolver(2).SolveMulti(context.Background(), stamp))
+			assert.NoError(t, stamp.Validate(hashcash.WithMaxAge(time.Minute)))
+
+			parsed, err := hashcash.MultiFromString(stamp.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, stamp.ToString(), parsed.ToString())
+			assert.Equal(t, tt.resource, parsed.GetResource())
+			assert.Equal(t, tt.algorithm, parsed.GetAlgorithm())
+			assert.Equal(t, tt.bits, parsed.GetBits())
+			assert.NoError(t, parsed.Validate())
+		})
+	}
+}
+
+func TestMultiStamp_Validate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	stamp, err := hashcash.NewMulti("172.21.0.4", 16, 2, now.Unix(), hashcash.WithRand(validRand))
+	assert.NoError(t, err)
+	assert.NoError(t, hashcash.NewSolver(0).SolveMulti(context.Background(), stamp))
+
+	assert.ErrorIs(t, stamp.Validate(hashcash.WithMaxAge(time.Minute), hashcash.WithNow(func() time.Time {
+		return now.Add(time.Hour)
+	})), hashcash.ErrExpired)
+
+	// every sub-stamp must be solved, so unsolved one invalidates the whole multi-stamp
+	data := stamp.ToString()
+	unsolved, err := hashcash.MultiFromString(data[:strings.LastIndexByte(data, ',')] + ",0")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, unsolved.Validate(), hashcash.ErrInvalidHash)
+}
+
+func TestNewMulti_SingleSubStamp(t *testing.T) {
+	t.Parallel()
+
+	stamp, err := hashcash.NewMulti("172.21.0.4", 12, 1, time.Now().Unix(), hashcash.WithRand(validRand))
+	assert.NoError(t, err)
+
+	// single sub-stamp is a plain stamp with difficulty of the multi-stamp
+	sub := stamp.GetStamps()[0]
+	assert.Equal(t, 12, sub.GetBits())
+	assert.Equal(t, hashcash.BitsCost(12), stamp.Cost())
+
+	plain, err := hashcash.New("172.21.0.4", 12, stamp.GetDate(), hashcash.WithRand(sub.GetRandValue()))
+	assert.NoError(t, err)
+	assert.Equal(t, plain.ToString(), sub.ToString())
+}
+
+func TestNewMulti_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		bits    int
+		count   int
+		opts    []hashcash.Option
+		wantErr error
+	}{
+		{
+			name:    "zero count",
+			bits:    16,
+			count:   0,
+			wantErr: hashcash.ErrInvalidCount,
+		},
+		{
+			name:    "too many sub-stamps",
+			bits:    16,
+			count:   hashcash.MaxSubStamps + 1,
+			wantErr: hashcash.ErrInvalidCount,
+		},
+		{
+			name:    "count is not below 2^bits",
+			bits:    2,
+			count:   4,
+			wantErr: hashcash.ErrInvalidCount,
+		},
+		{
+			name:    "bits above digest size",
+			bits:    161,
+			count:   2,
+			opts:    []hashcash.Option{hashcash.WithAlgorithm(hashcash.SHA1)},
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "classic format",
+			bits:    16,
+			count:   2,
+			opts:    []hashcash.Option{hashcash.WithClassicFormat("")},
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := hashcash.NewMulti("172.21.0.4", tt.bits, tt.count, time.Now().Unix(), tt.opts...)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMultiFromString_Strict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		field   string
+		wantErr error
+	}{
+		{
+			name:    "single stamp",
+			data:    "3:sha256:16:1656370862:172.21.0.4:" + validRand + ":0",
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+		{
+			name:    "unknown version",
+			data:    "multistamp:2:sha256:16:2:1656370862:172.21.0.4:" + validRand + ":1,2",
+			field:   "version",
+			wantErr: hashcash.ErrInvalidVersion,
+		},
+		{
+			name:    "bits with leading zero",
+			data:    "multistamp:1:sha256:016:2:1656370862:172.21.0.4:" + validRand + ":1,2",
+			field:   "difficulty",
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "too many sub-stamps",
+			data:    "multistamp:1:sha256:16:65:1656370862:172.21.0.4:" + validRand + ":1,2",
+			field:   "count",
+			wantErr: hashcash.ErrInvalidCount,
+		},
+		{
+			name:    "missing counter",
+			data:    "multistamp:1:sha256:16:3:1656370862:172.21.0.4:" + validRand + ":1,2",
+			field:   "counter",
+			wantErr: hashcash.ErrInvalidCounter,
+		},
+		{
+			name:    "empty counter",
+			data:    "multistamp:1:sha256:16:2:1656370862:172.21.0.4:" + validRand + ":1,",
+			field:   "counter",
+			wantErr: hashcash.ErrInvalidCounter,
+		},
+		{
+			name:    "unescaped ipv6 resource",
+			data:    "multistamp:1:sha256:16:2:1656370862:::1:" + validRand + ":1,2",
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := hashcash.MultiFromString(tt.data)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			var parseErr *hashcash.ParseError
+			if assert.True(t, errors.As(err, &parseErr)) {
+				assert.Equal(t, tt.field, parseErr.Field)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package hashcash
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Limits of stamp fields. They bound the amount of data, which verifier parses and hashes for a single stamp.
+const (
+	MaxStampSize     = 2048                      // MaxStampSize is maximum length of string representation of the stamp.
+	MaxResourceSize  = challenge.MaxResourceSize // MaxResourceSize is maximum length of the resource before escaping.
+	MaxExtensionSize = 256                       // MaxExtensionSize is maximum length of extension field of classic stamps.
+	MaxRandSize      = challenge.MaxRandSize     // MaxRandSize is maximum length of base64 rand value.
+	MaxCounterSize   = challenge.MaxUintSize     // MaxCounterSize is maximum length of counter, it fits any int64 counter.
+	MaxBits          = 512                       // MaxBits is maximum difficulty in bits, the digest size of SHA-512.
+)
+
+// stampParts is the number of fields of all stamp formats except legacy version 1.
+const stampParts = 7
+
+var (
+	ErrStampTooLarge    = errors.New("stamp is too large")
+	ErrInvalidFormat    = errors.New("invalid stamp format")
+	ErrInvalidVersion   = errors.New("invalid version")
+	ErrInvalidDate      = errors.New("invalid date")
+	ErrInvalidResource  = challenge.ErrInvalidResource
+	ErrInvalidExtension = errors.New("invalid extension")
+	ErrInvalidRand      = challenge.ErrInvalidRand
+	ErrInvalidCounter   = errors.New("invalid counter")
+)
+
+// ParseError is returned by FromString for malformed stamps. Err is one of the package errors,
+// e.g. ErrInvalidDate, and Field is the name of the invalid field or empty when the whole stamp is rejected.
+type ParseError struct {
+	Field string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	if e.Field == "" {
+		return "parse stamp: " + e.Err.Error()
+	}
+
+	return "parse stamp " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+func fieldError(field string, err error) error {
+	return &ParseError{Field: field, Err: err}
+}
+
+func parseVersion(data string) (int, error) {
+	switch data {
+	case "1":
+		return legacyVersion, nil
+	case "2":
+		return algorithmVersion, nil
+	case "3":
+		return Version, nil
+	default:
+		return 0, fieldError("version", ErrInvalidVersion)
+	}
+}
+
+func parseAlgorithm(data string) (Algorithm, error) {
+	alg := Algorithm(data)
+	if _, err := lookupAlgorithm(alg); err != nil {
+		return "", fieldError("algorithm", err)
+	}
+
+	return alg, nil
+}
+
+func parseDate(data string) (int64, error) {
+	date, ok := challenge.ParseUint(data, challenge.MaxDateSize)
+	if !ok {
+		return 0, fieldError("date", ErrInvalidDate)
+	}
+
+	return date, nil
+}
+
+func parseCounter(data string) (int, error) {
+	counter, ok := challenge.ParseUint(data, MaxCounterSize)
+	if !ok || counter > int64(int(^uint(0)>>1)) {
+		return 0, fieldError("counter", ErrInvalidCounter)
+	}
+
+	return int(counter), nil
+}
+
+func parseRand(data string) (string, error) {
+	if err := challenge.ValidateRand(data); err != nil {
+		return "", fieldError("rand", err)
+	}
+
+	return data, nil
+}
+
+// validateExtension checks, that extension of classic stamp is printable ASCII without ":" of allowed size.
+func validateExtension(ext string) error {
+	if len(ext) > MaxExtensionSize {
+		return ErrInvalidExtension
+	}
+
+	for i := 0; i < len(ext); i++ {
+		if ext[i] <= ' ' || ext[i] > '~' || ext[i] == ':' {
+			return ErrInvalidExtension
+		}
+	}
+
+	return nil
+}
+
+// unescapeResource parses escaped resource field of the stamp, see challenge.EscapeResource.
+func unescapeResource(data string) (string, error) {
+	resource, err := challenge.UnescapeResource(data)
+	if err != nil {
+		return "", fieldError("resource", err)
+	}
+
+	return resource, nil
+}
@@ -0,0 +1,305 @@
+package hashcash_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+const validRand = "FrZUho0yFjtWiiMonJTt55OFQ9k="
+
+func TestFromString_Strict(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		field   string
+		wantErr error
+	}{
+		{
+			name:    "too large stamp",
+			data:    "3:sha256:16:1656370862:" + strings.Repeat("a", hashcash.MaxStampSize) + ":" + validRand + ":0",
+			wantErr: hashcash.ErrStampTooLarge,
+		},
+		{
+			name:    "extra field",
+			data:    "3:sha256:16:1656370862:172.21.0.4:" + validRand + ":0:0",
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+		{
+			name:    "unescaped ipv6 resource",
+			data:    "3:sha256:16:1656370862:::1:" + validRand + ":0",
+			wantErr: hashcash.ErrInvalidFormat,
+		},
+		{
+			name:    "version with sign",
+			data:    "+3:sha256:16:1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "version",
+			wantErr: hashcash.ErrInvalidVersion,
+		},
+		{
+			name:    "bits above digest size",
+			data:    "3:sha256:513:1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "difficulty",
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "bits with leading zero",
+			data:    "3:sha256:016:1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "difficulty",
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "uppercase target",
+			data:    "3:sha256:0xFF:1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "difficulty",
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "too long target",
+			data:    "3:sha256:0x1" + strings.Repeat("0", 128) + ":1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "difficulty",
+			wantErr: hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:    "negative date",
+			data:    "3:sha256:16:-1656370862:172.21.0.4:" + validRand + ":0",
+			field:   "date",
+			wantErr: hashcash.ErrInvalidDate,
+		},
+		{
+			name:    "resource with space",
+			data:    "3:sha256:16:1656370862:172.21.0.4 :" + validRand + ":0",
+			field:   "resource",
+			wantErr: hashcash.ErrInvalidResource,
+		},
+		{
+			name:    "resource with lowercase escape",
+			data:    "3:sha256:16:1656370862:%3a%3a1:" + validRand + ":0",
+			field:   "resource",
+			wantErr: hashcash.ErrInvalidResource,
+		},
+		{
+			name:    "resource with needless escape",
+			data:    "3:sha256:16:1656370862:%31:" + validRand + ":0",
+			field:   "resource",
+			wantErr: hashcash.ErrInvalidResource,
+		},
+		{
+			name:    "resource with truncated escape",
+			data:    "3:sha256:16:1656370862:1%3:" + validRand + ":0",
+			field:   "resource",
+			wantErr: hashcash.ErrInvalidResource,
+		},
+		{
+			name:    "too long resource",
+			data:    "3:sha256:16:1656370862:" + strings.Repeat("a", hashcash.MaxResourceSize+1) + ":" + validRand + ":0",
+			field:   "resource",
+			wantErr: hashcash.ErrInvalidResource,
+		},
+		{
+			name:    "empty rand",
+			data:    "3:sha256:16:1656370862:172.21.0.4::0",
+			field:   "rand",
+			wantErr: hashcash.ErrInvalidRand,
+		},
+		{
+			name:    "rand is not base64",
+			data:    "3:sha256:16:1656370862:172.21.0.4:rand!:0",
+			field:   "rand",
+			wantErr: hashcash.ErrInvalidRand,
+		},
+		{
+			name:    "too long rand",
+			data:    "3:sha256:16:1656370862:172.21.0.4:" + strings.Repeat("A", hashcash.MaxRandSize+1) + ":0",
+			field:   "rand",
+			wantErr: hashcash.ErrInvalidRand,
+		},
+		{
+			name:    "counter overflow",
+			data:    "3:sha256:16:1656370862:172.21.0.4:" + validRand + ":9223372036854775808",
+			field:   "counter",
+			wantErr: hashcash.ErrInvalidCounter,
+		},
+		{
+			name:    "counter with sign",
+			data:    "3:sha256:16:1656370862:172.21.0.4:" + validRand + ":+1",
+			field:   "counter",
+			wantErr: hashcash.ErrInvalidCounter,
+		},
+		{
+			name:    "classic stamp with invalid extension",
+			data:    "1:20:130303:adam@cypherspace.org:x y:McMybZIhxKXu57jd:ckvi",
+			field:   "extension",
+			wantErr: hashcash.ErrInvalidExtension,
+		},
+		{
+			name:    "classic stamp with invalid counter",
+			data:    "1:20:130303:adam@cypherspace.org::McMybZIhxKXu57jd:ck-vi",
+			field:   "counter",
+			wantErr: hashcash.ErrInvalidCounter,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := hashcash.FromString(tt.data)
+			assert.ErrorIs(t, err, tt.wantErr)
+
+			var parseErr *hashcash.ParseError
+			if assert.True(t, errors.As(err, &parseErr)) {
+				assert.Equal(t, tt.field, parseErr.Field)
+			}
+		})
+	}
+}
+
+func TestStamp_EscapedResource(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		resource string
+		escaped  string
+	}{
+		{
+			name:     "ipv6 address",
+			resource: "::1",
+			escaped:  "%3A%3A1",
+		},
+		{
+			name:     "ipv6 address with zone",
+			resource: "fe80::1%eth0",
+			escaped:  "fe80%3A%3A1%25eth0",
+		},
+		{
+			name:     "non-ascii resource",
+			resource: "ресурс",
+			escaped:  "%D1%80%D0%B5%D1%81%D1%83%D1%80%D1%81",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.New(tt.resource, 8, time.Now().Unix(), hashcash.WithRand(validRand))
+			assert.NoError(t, err)
+			assert.Contains(t, stamp.ToString(), ":"+tt.escaped+":")
+
+			assert.NoError(t, stamp.GenerateHash(9999999))
+
+			parsed, err := hashcash.FromString(stamp.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.resource, parsed.GetResource())
+			assert.True(t, parsed.Verify())
+		})
+	}
+}
+
+func TestNew_Limits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		resource string
+		bits     int
+		opts     []hashcash.Option
+		wantErr  error
+	}{
+		{
+			name:     "too long resource",
+			resource: strings.Repeat("a", hashcash.MaxResourceSize+1),
+			wantErr:  hashcash.ErrInvalidResource,
+		},
+		{
+			name:     "bits above digest size",
+			resource: "test",
+			bits:     hashcash.MaxBits + 1,
+			wantErr:  hashcash.ErrInvalidDifficulty,
+		},
+		{
+			name:     "rand is not base64",
+			resource: "test",
+			opts:     []hashcash.Option{hashcash.WithRand("a:b")},
+			wantErr:  hashcash.ErrInvalidRand,
+		},
+		{
+			name:     "extension with separator",
+			resource: "test",
+			opts:     []hashcash.Option{hashcash.WithClassicFormat("a:b")},
+			wantErr:  hashcash.ErrInvalidExtension,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := hashcash.New(tt.resource, tt.bits, time.Now().Unix(), tt.opts...)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func FuzzFromString(f *testing.F) {
+	for _, seed := range []string{
+		"3:sha256:16:1656370862:172.21.0.4:" + validRand + ":28721",
+		"3:sha512:0xffff:1656370862:%3A%3A1:" + validRand + ":0",
+		"2:sha256:4:1656370862:172.21.0.4:" + validRand + ":2420",
+		"1:4:1656370862:172.21.0.4:" + validRand + ":2420",
+		"1:20:1303030600:adam@cypherspace.org::McMybZIhxKXu57jd:ckvi",
+		"1:20:130303:adam@cypherspace.org:x=1,2;y:McMybZIhxKXu57jd:ckvi",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, data string) {
+		stamp, err := hashcash.FromString(data)
+		if err != nil {
+			var parseErr *hashcash.ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("error %v is not ParseError", err)
+			}
+
+			return
+		}
+
+		// parsed stamp is written back exactly, otherwise its hash is not verified
+		if stamp.ToString() != data {
+			t.Fatalf("stamp %q is written as %q", data, stamp.ToString())
+		}
+
+		stamp.Verify()
+	})
+}
+
+func FuzzResource(f *testing.F) {
+	for _, seed := range []string{"172.21.0.4", "::1", "fe80::1%eth0", "%3A", "a b", ""} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, resource string) {
+		stamp, err := hashcash.New(resource, 0, 1656370862, hashcash.WithRand(validRand))
+		if err != nil {
+			return
+		}
+
+		parsed, err := hashcash.FromString(stamp.ToString())
+		if err != nil {
+			t.Fatalf("stamp %q is not parsed: %v", stamp.ToString(), err)
+		}
+
+		if parsed.GetResource() != resource {
+			t.Fatalf("resource %q is parsed as %q", resource, parsed.GetResource())
+		}
+	})
+}
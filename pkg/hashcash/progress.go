@@ -0,0 +1,113 @@
+package hashcash
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Progress is a snapshot of solving statistics, reported by Solver.
+type Progress struct {
+	Attempts         uint64        // Attempts is the number of hashes computed by all workers.
+	Elapsed          time.Duration // Elapsed is the time passed since solving started.
+	HashRate         float64       // HashRate is the average number of hashes per second.
+	ExpectedAttempts float64       // ExpectedAttempts is the mean number of hashes needed for the stamp difficulty.
+	Remaining        time.Duration // Remaining is the estimated time until ExpectedAttempts is reached, 0 once exceeded.
+	Done             bool          // Done is true for the last report, sent when solving is finished.
+}
+
+// ProgressFunc receives solving progress. It's called sequentially from a single goroutine.
+type ProgressFunc func(p Progress)
+
+// SolverOption configures Solver created by NewSolver.
+type SolverOption func(s *Solver)
+
+// WithProgress makes Solver call fn every interval while solving, and once more with Done set when it's finished.
+func WithProgress(interval time.Duration, fn ProgressFunc) SolverOption {
+	return func(s *Solver) {
+		s.progressInterval = interval
+		s.progressFunc = fn
+	}
+}
+
+// progressMeter counts attempts of all workers and builds Progress reports.
+type progressMeter struct {
+	attempts uint64 // first field to be 64-bit aligned for atomic operations
+	started  time.Time
+	expected float64
+}
+
+func newProgressMeter(expected float64) *progressMeter {
+	return &progressMeter{
+		started:  time.Now(),
+		expected: expected,
+	}
+}
+
+func (m *progressMeter) add(attempts uint64) {
+	atomic.AddUint64(&m.attempts, attempts)
+}
+
+func (m *progressMeter) report(done bool) Progress {
+	progress := Progress{
+		Attempts:         atomic.LoadUint64(&m.attempts),
+		Elapsed:          time.Since(m.started),
+		ExpectedAttempts: m.expected,
+		Done:             done,
+	}
+
+	if seconds := progress.Elapsed.Seconds(); seconds > 0 {
+		progress.HashRate = float64(progress.Attempts) / seconds
+	}
+
+	if left := m.expected - float64(progress.Attempts); left > 0 && progress.HashRate > 0 && !done {
+		progress.Remaining = secondsToDuration(left / progress.HashRate)
+	}
+
+	return progress
+}
+
+// secondsToDuration converts seconds to time.Duration, saturating on overflow.
+func secondsToDuration(seconds float64) time.Duration {
+	if seconds >= float64(math.MaxInt64)/float64(time.Second) {
+		return time.Duration(math.MaxInt64)
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// reportProgress starts periodic progress reports of the meter and returns function,
+// which stops them and sends the final report.
+func (s *Solver) reportProgress(meter *progressMeter) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		if s.progressInterval <= 0 {
+			<-stop
+
+			return
+		}
+
+		ticker := time.NewTicker(s.progressInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.progressFunc(meter.report(false))
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-stopped
+
+		s.progressFunc(meter.report(true))
+	}
+}
@@ -0,0 +1,124 @@
+package hashcash
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ctxCheckInterval is the number of attempts between context cancellation checks of a worker.
+const ctxCheckInterval = 1024
+
+var ErrCounterExhausted = errors.New("counter space exhausted")
+
+// Solver searches counter of the stamp in parallel, splitting counter space between workers.
+type Solver struct {
+	workers          int
+	progressInterval time.Duration
+	progressFunc     ProgressFunc
+}
+
+// NewSolver returns Solver with given workers count. Uses runtime.NumCPU() workers when count is not positive.
+func NewSolver(workers int, opts ...SolverOption) *Solver {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	solver := &Solver{
+		workers: workers,
+	}
+
+	for _, opt := range opts {
+		opt(solver)
+	}
+
+	return solver
+}
+
+// Solve finds counter, which makes stamp valid, and sets it to the stamp.
+// Worker i checks counters i+1, i+1+workers, i+1+2*workers and so on.
+// All workers are stopped when the first solution is found or context is done.
+// Returns context error on cancellation or deadline, stamp is not modified in this case.
+func (s *Solver) Solve(ctx context.Context, stamp *Stamp) error {
+	hashers := make([]*hasher, s.workers)
+
+	for i := range hashers {
+		h, err := newHasher(stamp)
+		if err != nil {
+			return err
+		}
+
+		hashers[i] = h
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	found := make(chan int, s.workers)
+	meter := newProgressMeter(stamp.Cost().ExpectedAttempts())
+
+	if s.progressFunc != nil {
+		defer s.reportProgress(meter)()
+	}
+
+	var wg sync.WaitGroup
+
+	for i, h := range hashers {
+		wg.Add(1)
+
+		go func(h *hasher, start int) {
+			defer wg.Done()
+
+			if counter, ok := s.work(ctx, h, start, meter); ok {
+				found <- counter
+
+				cancel()
+			}
+		}(h, i+1)
+	}
+
+	wg.Wait()
+	close(found)
+
+	if counter, ok := <-found; ok {
+		stamp.setCounter(counter)
+
+		return nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return ErrCounterExhausted
+}
+
+// work checks counters starting from start with step of workers count.
+// Attempts are added to the meter in batches of ctxCheckInterval to avoid contention between workers.
+func (s *Solver) work(ctx context.Context, h *hasher, start int, meter *progressMeter) (int, bool) {
+	var attempts uint64
+
+	defer func() {
+		meter.add(attempts % ctxCheckInterval)
+	}()
+
+	// counter becomes negative on overflow, which means that worker's part of counter space is exhausted
+	for counter := start; counter > 0; counter += s.workers {
+		attempts++
+		if attempts%ctxCheckInterval == 0 {
+			meter.add(ctxCheckInterval)
+
+			if ctx.Err() != nil {
+				return 0, false
+			}
+		}
+
+		if h.check(counter) {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,99 @@
+package hashcash_test
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestSolver_Solve(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		workers int
+		bits    int
+	}{
+		{
+			name:    "single worker",
+			workers: 1,
+			bits:    12,
+		},
+		{
+			name:    "several workers",
+			workers: 4,
+			bits:    16,
+		},
+		{
+			name:    "default workers count",
+			workers: 0,
+			bits:    16,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.New("test", tt.bits, time.Now().Unix())
+			assert.NoError(t, err)
+
+			assert.NoError(t, hashcash.NewSolver(tt.workers).Solve(context.Background(), stamp))
+			assert.True(t, stamp.Verify())
+		})
+	}
+}
+
+func TestSolver_Solve_Cancel(t *testing.T) {
+	t.Parallel()
+
+	stamp, err := hashcash.New("test", 200, time.Now().Unix())
+	assert.NoError(t, err)
+
+	before := stamp.ToString()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = hashcash.NewSolver(2).Solve(ctx, stamp)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, before, stamp.ToString())
+}
+
+func TestSolver_Solve_Progress(t *testing.T) {
+	t.Parallel()
+
+	stamp, err := hashcash.New("test", 200, time.Now().Unix())
+	assert.NoError(t, err)
+
+	var reports []hashcash.Progress
+
+	solver := hashcash.NewSolver(2, hashcash.WithProgress(10*time.Millisecond, func(p hashcash.Progress) {
+		reports = append(reports, p)
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, solver.Solve(ctx, stamp), context.DeadlineExceeded)
+
+	if assert.Greater(t, len(reports), 1) {
+		last := reports[len(reports)-1]
+
+		assert.True(t, last.Done)
+		assert.Greater(t, last.Attempts, uint64(0))
+		assert.Greater(t, last.HashRate, float64(0))
+		assert.Equal(t, math.Pow(2, 200), last.ExpectedAttempts)
+
+		for _, report := range reports[:len(reports)-1] {
+			assert.False(t, report.Done)
+			assert.Equal(t, report.HashRate > 0, report.Remaining > 0)
+			assert.LessOrEqual(t, report.Attempts, last.Attempts)
+		}
+	}
+}
@@ -1,99 +1,195 @@
 package hashcash
 
 import (
-	"crypto/rand"
-	"crypto/sha1" //nolint:gosec // it's ok for hashcash system
-	"encoding/base64"
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"strconv"
+	"math/big"
 	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
 )
 
-const Version = 1
+// Version is the current stamp format version, which contains hash algorithm and difficulty in bits or target.
+const Version = 3
 
-const defaultCounter = 0
+// legacyVersion is the stamp format without algorithm field, always hashed with SHA-1.
+const legacyVersion = 1
 
-const randBytesSize = 20
+// algorithmVersion is the stamp format with algorithm field and difficulty in leading hex '0' characters.
+const algorithmVersion = 2
 
-const zeroByte = 48
+const defaultCounter = 0
 
 // Stamp is a struct for hashcash PoW algorithm to prevent DoS attacks.
 // Contains all the necessary fields for hash generation and validation hashcash hash.
 type Stamp struct {
-	version   int
-	zeroCount int
-	date      int64
-	resource  string
-	rand      string
-	counter   int
-}
-
-// New returns Stamp implementation of hashcash system with given resource and leading zero count.
-// Returns error when can't generate random base64 string.
-func New(resource string, zeroCount int, date int64) (*Stamp, error) {
-	randBase64, err := generateRandBase64(randBytesSize)
-	if err != nil {
-		return nil, err
+	version     int
+	classic     bool
+	algorithm   Algorithm
+	bits        int
+	target      *big.Int
+	date        int64
+	dateText    string
+	resource    string
+	ext         string
+	rand        string
+	counter     int
+	counterText string
+}
+
+// Option configures Stamp created by New.
+type Option func(s *Stamp)
+
+// WithAlgorithm sets hash algorithm of the stamp. DefaultAlgorithm is used when option is omitted.
+func WithAlgorithm(alg Algorithm) Option {
+	return func(s *Stamp) {
+		s.algorithm = alg
+	}
+}
+
+// WithRand sets rand field of the stamp instead of generated random value,
+// e.g. to embed data, which allows issuer to authenticate the stamp. Rand must be base64 string up to MaxRandSize.
+func WithRand(rand string) Option {
+	return func(s *Stamp) {
+		s.rand = rand
 	}
+}
 
-	return &Stamp{
+// New returns Stamp implementation of hashcash system with given resource and leading zero bits count.
+// Returns error when can't generate random base64 string, hash algorithm is unknown
+// or stamp fields exceed limits of FromString.
+func New(resource string, bits int, date int64, opts ...Option) (*Stamp, error) {
+	stamp := &Stamp{
 		version:   Version,
-		zeroCount: zeroCount,
+		algorithm: DefaultAlgorithm,
+		bits:      bits,
 		date:      date,
 		resource:  resource,
-		rand:      randBase64,
 		counter:   defaultCounter,
-	}, nil
+	}
+
+	for _, opt := range opts {
+		opt(stamp)
+	}
+
+	if err := stamp.validate(); err != nil {
+		return nil, err
+	}
+
+	size := challenge.RandBytesSize
+	if stamp.classic {
+		if stamp.algorithm != SHA1 || stamp.target != nil {
+			return nil, ErrClassicFormat
+		}
+
+		size = classicRandBytesSize
+	}
+
+	if stamp.rand != "" {
+		if err := challenge.ValidateRand(stamp.rand); err != nil {
+			return nil, err
+		}
+
+		return stamp, nil
+	}
+
+	randBase64, err := challenge.GenerateRand(size)
+	if err != nil {
+		return nil, err
+	}
+
+	stamp.rand = randBase64
+
+	return stamp, nil
 }
 
 // FromString parses data string to hasshcash Stamp.
-// String should be formatted as "version:zeroCount:date:resource:rand:counter".
+// String should be formatted as "version:algorithm:difficulty:date:resource:rand:counter",
+// where difficulty is leading zero bits count or hex target with "0x" prefix.
+// Version 2 stamps are formatted the same way, but difficulty is leading hex '0' characters count.
+// Version 1 stamps formatted as "1:zeroCount:date:resource:rand:counter" are parsed as SHA-1 stamps.
+// Standard Hashcash version 1 stamps "1:bits:YYMMDD[hhmm[ss]]:resource:ext:rand:counter" are parsed as classic ones.
+// Every field is checked strictly against its format and size limits, so ToString of parsed stamp returns data.
+// Returns *ParseError, which wraps one of the package errors, when stamp is malformed.
 func FromString(data string) (*Stamp, error) {
-	parts := strings.Split(data, ":")
-	if len(parts) != 6 {
-		return nil, errors.New("invalid message format")
+	if len(data) > MaxStampSize {
+		return nil, &ParseError{Err: ErrStampTooLarge}
 	}
 
-	version, err := strconv.Atoi(parts[0])
-	if err != nil || version != Version {
-		return nil, errors.New("invalid version")
-	}
+	// extra fields are left in the last part, so stamp with them has too many parts
+	parts := strings.SplitN(data, ":", stampParts+1)
 
-	zeroCount, err := strconv.Atoi(parts[1])
+	version, err := parseVersion(parts[0])
 	if err != nil {
-		return nil, errors.New("invalid zero count")
+		return nil, err
 	}
 
-	date, err := strconv.ParseInt(parts[2], 10, 64)
-	if err != nil {
-		return nil, errors.New("invalid date")
+	algorithm := SHA1
+
+	switch {
+	case version == classicVersion && len(parts) == stampParts:
+		return parseClassic(parts[1:])
+	case version == legacyVersion:
+		if len(parts) != stampParts-1 {
+			return nil, &ParseError{Err: ErrInvalidFormat}
+		}
+
+		parts = parts[1:]
+	default:
+		if len(parts) != stampParts {
+			return nil, &ParseError{Err: ErrInvalidFormat}
+		}
+
+		if algorithm, err = parseAlgorithm(parts[1]); err != nil {
+			return nil, err
+		}
+
+		parts = parts[2:]
 	}
 
-	counter, err := strconv.Atoi(parts[5])
+	bits, target, err := parseDifficulty(version, parts[0])
 	if err != nil {
-		return nil, errors.New("invalid counter")
+		return nil, fieldError("difficulty", err)
 	}
 
-	return &Stamp{
+	stamp := &Stamp{
 		version:   version,
-		zeroCount: zeroCount,
-		date:      date,
-		resource:  parts[3],
-		rand:      parts[4],
-		counter:   counter,
-	}, nil
+		algorithm: algorithm,
+		bits:      bits,
+		target:    target,
+	}
+
+	if stamp.date, err = parseDate(parts[1]); err != nil {
+		return nil, err
+	}
+
+	if stamp.resource, err = unescapeResource(parts[2]); err != nil {
+		return nil, err
+	}
+
+	if stamp.rand, err = parseRand(parts[3]); err != nil {
+		return nil, err
+	}
+
+	if stamp.counter, err = parseCounter(parts[4]); err != nil {
+		return nil, err
+	}
+
+	return stamp, nil
 }
 
-// GenerateHash generates hash of the stamp, contains needed leading zero bytes.
-// Returns nil if everything is ok and error if context deadline exceeded.
+// GenerateHash generates hash of the stamp, contains needed leading zero bits or below the target.
+// Returns nil if everything is ok and error if max attempts exceeded.
 func (s *Stamp) GenerateHash(attempts int) error {
+	h, err := newHasher(s)
+	if err != nil {
+		return err
+	}
+
 	for i := 1; i < attempts; i++ {
-		s.counter = i
-		hashStr := generateSha1(s.ToString())
+		if h.check(i) {
+			s.setCounter(i)
 
-		if checkHash(hashStr, s.zeroCount) {
 			return nil
 		}
 	}
@@ -103,54 +199,115 @@ func (s *Stamp) GenerateHash(attempts int) error {
 
 // Verify verifies stamp
 func (s *Stamp) Verify() bool {
-	hash := generateSha1(s.ToString())
+	digest, err := generateHash(s.algorithm, s.ToString())
+	if err != nil {
+		return false
+	}
 
-	return checkHash(hash, s.zeroCount)
+	return checkDigest(digest, s.bits, s.target)
 }
 
 // ToString returns string representation of the stamp data, separated with ":".
 func (s *Stamp) ToString() string {
-	return fmt.Sprintf("%d:%d:%d:%s:%s:%d", s.version, s.zeroCount, s.date, s.resource, s.rand, s.counter)
+	if s.classic {
+		return s.formatClassic()
+	}
+
+	if s.version == legacyVersion {
+		return fmt.Sprintf(
+			"%d:%s:%d:%s:%s:%d", s.version, s.formatDifficulty(), s.date, challenge.EscapeResource(s.resource), s.rand, s.counter,
+		)
+	}
+
+	return fmt.Sprintf(
+		"%d:%s:%s:%d:%s:%s:%d", s.version, s.algorithm, s.formatDifficulty(), s.date, challenge.EscapeResource(s.resource), s.rand, s.counter,
+	)
+}
+
+// GetVersion returns format version of the stamp, 1 for both legacy and classic stamps.
+func (s *Stamp) GetVersion() int {
+	return s.version
 }
 
+// GetRandValue returns base64 rand field of the stamp.
 func (s *Stamp) GetRandValue() string {
 	return s.rand
 }
 
+// GetResource returns resource of the stamp, as it was given to New, without escaping.
 func (s *Stamp) GetResource() string {
 	return s.resource
 }
 
-func generateRandBase64(randBytesSize int) (string, error) {
-	bytes := make([]byte, randBytesSize)
+// GetDate returns unix time of the stamp creation.
+func (s *Stamp) GetDate() int64 {
+	return s.date
+}
+
+// GetAlgorithm returns hash algorithm of the stamp.
+func (s *Stamp) GetAlgorithm() Algorithm {
+	return s.algorithm
+}
 
-	if _, err := rand.Read(bytes); err != nil {
-		return "", err
+// GetBits returns required leading zero bits count. Returns 0 when stamp has target difficulty.
+func (s *Stamp) GetBits() int {
+	return s.bits
+}
+
+// GetTarget returns copy of the target, digest should be below, or nil when stamp has bits difficulty.
+func (s *Stamp) GetTarget() *big.Int {
+	if s.target == nil {
+		return nil
 	}
 
-	return base64.StdEncoding.EncodeToString(bytes), nil
+	return new(big.Int).Set(s.target)
 }
 
-func generateSha1(data string) string {
-	hash := sha1.New() //nolint:gosec // it's ok for hashcash system
-	hash.Write([]byte(data))
+// GetCounter returns counter of the stamp. Counter of classic stamp minted by another tool is 0,
+// when it isn't encoded the same way as this package does.
+func (s *Stamp) GetCounter() int {
+	return s.counter
+}
 
-	return hex.EncodeToString(hash.Sum(nil))
+// setCounter sets counter, dropping verbatim counter text of parsed classic stamp.
+func (s *Stamp) setCounter(counter int) {
+	s.counter = counter
+	s.counterText = ""
 }
 
-func checkHash(hash string, zeroCount int) bool {
-	// out of range protection
-	if len(hash) < zeroCount {
-		return false
+// validate checks fields of new stamp, so it can be parsed back by FromString.
+func (s *Stamp) validate() error {
+	if _, err := lookupAlgorithm(s.algorithm); err != nil {
+		return err
 	}
 
-	leadingNumbers := hash[:zeroCount]
+	if err := validateDifficulty(s.bits, s.target); err != nil {
+		return err
+	}
 
-	for _, number := range leadingNumbers {
-		if number != zeroByte {
-			return false
-		}
+	if s.date < 0 {
+		return ErrInvalidDate
+	}
+
+	if err := challenge.ValidateResource(s.resource); err != nil {
+		return err
 	}
 
-	return true
+	return validateExtension(s.ext)
+}
+
+func (s *Stamp) formatDifficulty() string {
+	return formatDifficulty(s.version, s.bits, s.target)
+}
+
+func generateHash(alg Algorithm, data string) ([]byte, error) {
+	newHash, err := lookupAlgorithm(alg)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := newHash()
+	hash.Write([]byte(data))
+
+	return hash.Sum(nil), nil
 }
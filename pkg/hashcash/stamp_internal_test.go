@@ -1,45 +1,18 @@
 package hashcash
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func Test_checkHash(t *testing.T) {
+func Test_checkDigest_Target(t *testing.T) {
 	t.Parallel()
 
-	tests := []struct {
-		name      string
-		hash      string
-		zeroCount int
-		want      bool
-	}{
-		{
-			name:      "valid hash with 4 leading zero",
-			hash:      "0000aek01f",
-			zeroCount: 4,
-			want:      true,
-		},
-		{
-			name:      "invalid hash with 4 ending zero",
-			hash:      "aek01f0000",
-			zeroCount: 3,
-			want:      false,
-		},
-		{
-			name:      "invalid leading zero count",
-			hash:      "0aef00k0f",
-			zeroCount: 2,
-			want:      false,
-		},
-	}
-	for _, tt := range tests {
-		tt := tt
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
+	target := big.NewInt(0x0100)
 
-			assert.Equal(t, tt.want, checkHash(tt.hash, tt.zeroCount))
-		})
-	}
+	assert.True(t, checkDigest([]byte{0x00, 0xff}, 0, target))
+	assert.False(t, checkDigest([]byte{0x01, 0x00}, 0, target))
+	assert.False(t, checkDigest([]byte{0x01, 0x01}, 0, target))
 }
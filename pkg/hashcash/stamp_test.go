@@ -1,6 +1,8 @@
 package hashcash_test
 
 import (
+	"math/big"
+	"strings"
 	"testing"
 	"time"
 
@@ -13,28 +15,28 @@ func TestStamp_Generate_Verify(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name      string
-		zeroCount int
+		name string
+		bits int
 	}{
 		{
-			name:      "1 leading zero",
-			zeroCount: 1,
+			name: "4 leading zero bits",
+			bits: 4,
 		},
 		{
-			name:      "2 leading zeros",
-			zeroCount: 2,
+			name: "8 leading zero bits",
+			bits: 8,
 		},
 		{
-			name:      "3 leading zeros",
-			zeroCount: 3,
+			name: "13 leading zero bits",
+			bits: 13,
 		},
 		{
-			name:      "4 leading zeros",
-			zeroCount: 4,
+			name: "16 leading zero bits",
+			bits: 16,
 		},
 		{
-			name:      "5 leading zeros",
-			zeroCount: 5,
+			name: "18 leading zero bits",
+			bits: 18,
 		},
 	}
 	for _, tt := range tests {
@@ -42,7 +44,7 @@ func TestStamp_Generate_Verify(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			stamp, err := hashcash.New("test", tt.zeroCount, time.Now().Unix())
+			stamp, err := hashcash.New("test", tt.bits, time.Now().Unix())
 			assert.NoError(t, err)
 
 			assert.NoError(t, stamp.GenerateHash(9999999))
@@ -51,6 +53,25 @@ func TestStamp_Generate_Verify(t *testing.T) {
 	}
 }
 
+func TestStamp_Generate_Verify_Target(t *testing.T) {
+	t.Parallel()
+
+	// 3/4 of digests with 16 leading zero bits are below the target.
+	target := new(big.Int).Lsh(big.NewInt(3), 256-18)
+
+	stamp, err := hashcash.New("test", 0, time.Now().Unix(), hashcash.WithTarget(target))
+	assert.NoError(t, err)
+	assert.Equal(t, target, stamp.GetTarget())
+
+	assert.NoError(t, stamp.GenerateHash(9999999))
+	assert.True(t, stamp.Verify())
+
+	parsed, err := hashcash.FromString(stamp.ToString())
+	assert.NoError(t, err)
+	assert.Equal(t, target, parsed.GetTarget())
+	assert.True(t, parsed.Verify())
+}
+
 func TestStamp_ToString(t *testing.T) {
 	now := time.Now().Unix()
 	stamp, err := hashcash.New("testResource", 4, now)
@@ -58,3 +79,136 @@ func TestStamp_ToString(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "testResource", stamp.GetResource())
 }
+
+func TestStamp_Algorithms(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range hashcash.Algorithms() {
+		alg := alg
+		t.Run(string(alg), func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.New("test", 12, time.Now().Unix(), hashcash.WithAlgorithm(alg))
+			assert.NoError(t, err)
+			assert.Equal(t, alg, stamp.GetAlgorithm())
+
+			assert.NoError(t, stamp.GenerateHash(9999999))
+			assert.True(t, stamp.Verify())
+
+			parsed, err := hashcash.FromString(stamp.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, stamp.ToString(), parsed.ToString())
+			assert.True(t, parsed.Verify())
+		})
+	}
+}
+
+func TestParseAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	alg, err := hashcash.ParseAlgorithm("sha512_256")
+	assert.NoError(t, err)
+	assert.Equal(t, hashcash.SHA512256, alg)
+
+	for _, invalid := range []string{"", "md4", "SHA256"} {
+		_, err := hashcash.ParseAlgorithm(invalid)
+		assert.ErrorIs(t, err, hashcash.ErrUnknownAlgorithm, invalid)
+	}
+}
+
+func TestNew_UnknownAlgorithm(t *testing.T) {
+	_, err := hashcash.New("test", 12, time.Now().Unix(), hashcash.WithAlgorithm("md4"))
+
+	assert.ErrorIs(t, err, hashcash.ErrUnknownAlgorithm)
+}
+
+func TestFromString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		alg     hashcash.Algorithm
+		bits    int
+		valid   bool
+		wantErr bool
+	}{
+		{
+			name:  "version 1 stamp is verified with sha1",
+			data:  "1:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			alg:   hashcash.SHA1,
+			bits:  16,
+			valid: true,
+		},
+		{
+			name:  "version 2 stamp with wrong counter",
+			data:  "2:sha256:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			alg:   hashcash.SHA256,
+			bits:  16,
+			valid: false,
+		},
+		{
+			name:  "version 3 stamp with bits",
+			data:  "3:sha256:13:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			alg:   hashcash.SHA256,
+			bits:  13,
+			valid: false,
+		},
+		{
+			name:  "version 3 stamp with target",
+			data:  "3:sha256:0x" + strings.Repeat("f", 64) + ":1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+			alg:   hashcash.SHA256,
+			valid: true,
+		},
+		{
+			name:    "version 3 stamp with zero target",
+			data:    "3:sha256:0x0:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+			wantErr: true,
+		},
+		{
+			name:    "version 2 stamp with target",
+			data:    "2:sha256:0xff:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+			wantErr: true,
+		},
+		{
+			name:    "negative bits",
+			data:    "3:sha256:-1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+			wantErr: true,
+		},
+		{
+			name:    "version 2 stamp with unknown algorithm",
+			data:    "2:md4:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			wantErr: true,
+		},
+		{
+			name:    "version 2 stamp without algorithm",
+			data:    "2:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			data:    "4:sha256:4:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:2420",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.alg, stamp.GetAlgorithm())
+			assert.Equal(t, tt.bits, stamp.GetBits())
+			assert.Equal(t, tt.data, stamp.ToString())
+			assert.Equal(t, tt.valid, stamp.Verify())
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package hashcash
+
+import (
+	"errors"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var (
+	ErrInvalidHash = errors.New("stamp hash does not satisfy difficulty")
+	ErrExpired     = challenge.ErrExpired
+	ErrFutureDate  = challenge.ErrFutureDate
+)
+
+// VerifyOption configures checks of Stamp.Validate.
+type VerifyOption = challenge.DateOption
+
+// WithMaxAge rejects stamps, which date is older than maxAge.
+func WithMaxAge(maxAge time.Duration) VerifyOption {
+	return challenge.WithMaxAge(maxAge)
+}
+
+// WithClockSkew rejects stamps, which date is ahead of current time more than tolerated clock skew.
+func WithClockSkew(skew time.Duration) VerifyOption {
+	return challenge.WithClockSkew(skew)
+}
+
+// WithNow sets source of current time for date checks. time.Now is used by default.
+func WithNow(now func() time.Time) VerifyOption {
+	return challenge.WithNow(now)
+}
+
+// Validate verifies stamp date with given options and stamp hash.
+// Returns ErrExpired, ErrFutureDate or ErrInvalidHash when stamp is not valid.
+func (s *Stamp) Validate(opts ...VerifyOption) error {
+	if err := challenge.ValidateDate(s.date, opts...); err != nil {
+		return err
+	}
+
+	if !s.Verify() {
+		return ErrInvalidHash
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package hashcash_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+func TestStamp_Validate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2022, time.June, 28, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		date    time.Time
+		solve   bool
+		opts    []hashcash.VerifyOption
+		wantErr error
+	}{
+		{
+			name:  "without date checks",
+			date:  now.Add(-24 * time.Hour),
+			solve: true,
+		},
+		{
+			name:  "fresh stamp",
+			date:  now.Add(-time.Minute),
+			solve: true,
+			opts:  []hashcash.VerifyOption{hashcash.WithMaxAge(2 * time.Minute), hashcash.WithClockSkew(0)},
+		},
+		{
+			name:    "expired stamp",
+			date:    now.Add(-3 * time.Minute),
+			solve:   true,
+			opts:    []hashcash.VerifyOption{hashcash.WithMaxAge(2 * time.Minute)},
+			wantErr: hashcash.ErrExpired,
+		},
+		{
+			name:  "future stamp within clock skew",
+			date:  now.Add(20 * time.Second),
+			solve: true,
+			opts:  []hashcash.VerifyOption{hashcash.WithMaxAge(2 * time.Minute), hashcash.WithClockSkew(30 * time.Second)},
+		},
+		{
+			name:    "future stamp out of clock skew",
+			date:    now.Add(time.Minute),
+			solve:   true,
+			opts:    []hashcash.VerifyOption{hashcash.WithMaxAge(2 * time.Minute), hashcash.WithClockSkew(30 * time.Second)},
+			wantErr: hashcash.ErrFutureDate,
+		},
+		{
+			name:    "not solved stamp",
+			date:    now,
+			opts:    []hashcash.VerifyOption{hashcash.WithMaxAge(2 * time.Minute)},
+			wantErr: hashcash.ErrInvalidHash,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			stamp, err := hashcash.New("test", 20, tt.date.Unix())
+			assert.NoError(t, err)
+
+			if tt.solve {
+				assert.NoError(t, hashcash.NewSolver(0).Solve(context.Background(), stamp))
+			}
+
+			opts := append(tt.opts, hashcash.WithNow(func() time.Time { return now }))
+
+			assert.ErrorIs(t, stamp.Validate(opts...), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package challenge contains validation of fields, which are common for hashcash stamps and other puzzles.
+package challenge
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrExpired    = errors.New("challenge is expired")
+	ErrFutureDate = errors.New("challenge date is in the future")
+)
+
+// DateOption configures checks of ValidateDate.
+type DateOption func(o *dateOptions)
+
+type dateOptions struct {
+	maxAge      time.Duration
+	clockSkew   time.Duration
+	checkFuture bool
+	now         func() time.Time
+}
+
+// WithMaxAge rejects challenges, which date is older than maxAge.
+func WithMaxAge(maxAge time.Duration) DateOption {
+	return func(o *dateOptions) {
+		o.maxAge = maxAge
+	}
+}
+
+// WithClockSkew rejects challenges, which date is ahead of current time more than tolerated clock skew.
+func WithClockSkew(skew time.Duration) DateOption {
+	return func(o *dateOptions) {
+		o.clockSkew = skew
+		o.checkFuture = true
+	}
+}
+
+// WithNow sets source of current time for date checks. time.Now is used by default.
+func WithNow(now func() time.Time) DateOption {
+	return func(o *dateOptions) {
+		o.now = now
+	}
+}
+
+// ValidateDate checks unix date of the challenge against maximum age and clock skew of options.
+// Returns ErrExpired or ErrFutureDate, when date is out of allowed range.
+func ValidateDate(date int64, opts ...DateOption) error {
+	options := dateOptions{
+		now: time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.maxAge > 0 || options.checkFuture {
+		age := options.now().Sub(time.Unix(date, 0))
+
+		if options.checkFuture && age < -options.clockSkew {
+			return ErrFutureDate
+		}
+
+		if options.maxAge > 0 && age > options.maxAge {
+			return ErrExpired
+		}
+	}
+
+	return nil
+}
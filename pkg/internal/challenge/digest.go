@@ -0,0 +1,24 @@
+package challenge
+
+// HasLeadingZeroBits reports whether digest starts with at least bits zero bits.
+func HasLeadingZeroBits(digest []byte, bits int) bool {
+	// out of range protection
+	if bits < 0 || bits > len(digest)*8 {
+		return false
+	}
+
+	fullBytes := bits / 8
+
+	for _, b := range digest[:fullBytes] {
+		if b != 0 {
+			return false
+		}
+	}
+
+	restBits := bits % 8
+	if restBits == 0 {
+		return true
+	}
+
+	return digest[fullBytes]>>(8-restBits) == 0
+}
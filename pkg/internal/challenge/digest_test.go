@@ -0,0 +1,65 @@
+package challenge_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+func TestHasLeadingZeroBits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		digest []byte
+		bits   int
+		want   bool
+	}{
+		{
+			name:   "valid digest with 16 leading zero bits",
+			digest: []byte{0x00, 0x00, 0xae, 0x01},
+			bits:   16,
+			want:   true,
+		},
+		{
+			name:   "valid digest with 13 leading zero bits",
+			digest: []byte{0x00, 0x07, 0xae, 0x01},
+			bits:   13,
+			want:   true,
+		},
+		{
+			name:   "invalid digest with 12 leading zero bits",
+			digest: []byte{0x00, 0x08, 0xae, 0x01},
+			bits:   13,
+			want:   false,
+		},
+		{
+			name:   "invalid digest with ending zero bits",
+			digest: []byte{0xae, 0x01, 0x00, 0x00},
+			bits:   12,
+			want:   false,
+		},
+		{
+			name:   "bits out of digest range",
+			digest: []byte{0x00, 0x00},
+			bits:   17,
+			want:   false,
+		},
+		{
+			name:   "zero bits",
+			digest: []byte{0xff},
+			bits:   0,
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, challenge.HasLeadingZeroBits(tt.digest, tt.bits))
+		})
+	}
+}
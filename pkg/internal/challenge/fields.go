@@ -0,0 +1,182 @@
+package challenge
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// Limits of common fields. They bound the amount of data, which verifier parses and hashes for a single challenge.
+const (
+	MaxResourceSize = 256 // MaxResourceSize is maximum length of the resource before escaping.
+	MaxRandSize     = 128 // MaxRandSize is maximum length of base64 rand value.
+	MaxDateSize     = 19  // MaxDateSize fits decimal unix time of any int64 date.
+	MaxUintSize     = 19  // MaxUintSize fits decimal representation of any int64.
+)
+
+// RandBytesSize is the number of random bytes of generated rand values.
+const RandBytesSize = 20
+
+var (
+	ErrInvalidResource = errors.New("invalid resource")
+	ErrInvalidRand     = errors.New("invalid rand")
+)
+
+// Rand returns rand, when it's valid base64 string up to MaxRandSize, or generates rand value, when it's empty.
+func Rand(rand string) (string, error) {
+	if rand == "" {
+		return GenerateRand(RandBytesSize)
+	}
+
+	if err := ValidateRand(rand); err != nil {
+		return "", err
+	}
+
+	return rand, nil
+}
+
+// GenerateRand returns base64 string of size random bytes.
+func GenerateRand(size int) (string, error) {
+	bytes := make([]byte, size)
+
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(bytes), nil
+}
+
+// ValidateRand checks, that rand is base64 string of allowed size, so it never contains field separator.
+func ValidateRand(rand string) error {
+	if rand == "" || len(rand) > MaxRandSize {
+		return ErrInvalidRand
+	}
+
+	data := strings.TrimRight(rand, "=")
+	if len(rand)-len(data) > 2 || data == "" {
+		return ErrInvalidRand
+	}
+
+	for i := 0; i < len(data); i++ {
+		if !IsBase64Char(data[i]) {
+			return ErrInvalidRand
+		}
+	}
+
+	return nil
+}
+
+// ValidateResource checks size of the resource. Any resource can be written to the challenge, see EscapeResource.
+func ValidateResource(resource string) error {
+	if len(resource) > MaxResourceSize {
+		return ErrInvalidResource
+	}
+
+	return nil
+}
+
+// ParseUint parses canonical decimal representation of non-negative integer: no sign and no leading zeros.
+func ParseUint(data string, maxSize int) (int64, bool) {
+	if data == "" || len(data) > maxSize || (data[0] == '0' && len(data) > 1) || !IsDigits(data) {
+		return 0, false
+	}
+
+	value, err := strconv.ParseInt(data, 10, 64)
+
+	return value, err == nil
+}
+
+// IsDigits reports whether data consists of decimal digits only.
+func IsDigits(data string) bool {
+	for i := 0; i < len(data); i++ {
+		if data[i] < '0' || data[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsBase64Char reports whether byte is a digit of standard base64 alphabet.
+func IsBase64Char(c byte) bool {
+	return c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '+' || c == '/'
+}
+
+// isResourceChar reports whether byte is written to the resource field as is.
+func isResourceChar(c byte) bool {
+	return c > ' ' && c <= '~' && c != ':' && c != '%'
+}
+
+const upperHex = "0123456789ABCDEF"
+
+// EscapeResource returns resource as it's written to the challenge. Field separator ":", "%"
+// and bytes outside of printable ASCII are percent-encoded, e.g. IPv6 address "::1" is written as "%3A%3A1".
+func EscapeResource(resource string) string {
+	escaped := 0
+
+	for i := 0; i < len(resource); i++ {
+		if !isResourceChar(resource[i]) {
+			escaped++
+		}
+	}
+
+	if escaped == 0 {
+		return resource
+	}
+
+	var b strings.Builder
+
+	b.Grow(len(resource) + 2*escaped)
+
+	for i := 0; i < len(resource); i++ {
+		c := resource[i]
+		if isResourceChar(c) {
+			b.WriteByte(c)
+
+			continue
+		}
+
+		b.WriteByte('%')
+		b.WriteByte(upperHex[c>>4])
+		b.WriteByte(upperHex[c&0x0f])
+	}
+
+	return b.String()
+}
+
+// UnescapeResource parses resource written by EscapeResource. Only canonical escaping is accepted,
+// so the challenge string, covered by its hash, is restored exactly. Returns ErrInvalidResource otherwise.
+func UnescapeResource(data string) (string, error) {
+	var b strings.Builder
+
+	b.Grow(len(data))
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		switch {
+		case isResourceChar(c):
+			b.WriteByte(c)
+		case c == '%' && i+2 < len(data):
+			hi, lo := strings.IndexByte(upperHex, data[i+1]), strings.IndexByte(upperHex, data[i+2])
+			if hi < 0 || lo < 0 || isResourceChar(byte(hi<<4|lo)) {
+				return "", ErrInvalidResource
+			}
+
+			b.WriteByte(byte(hi<<4 | lo))
+
+			i += 2
+		default:
+			return "", ErrInvalidResource
+		}
+	}
+
+	resource := b.String()
+	if err := ValidateResource(resource); err != nil {
+		return "", err
+	}
+
+	return resource, nil
+}
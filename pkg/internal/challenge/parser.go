@@ -0,0 +1,127 @@
+package challenge
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// separator of challenge fields.
+const separator = ":"
+
+var (
+	ErrTooLarge       = errors.New("challenge is too large")
+	ErrInvalidFormat  = errors.New("invalid challenge format")
+	ErrInvalidVersion = errors.New("invalid version")
+	ErrInvalidDate    = errors.New("invalid date")
+	ErrInvalidNumber  = errors.New("invalid number")
+)
+
+// FieldError is returned by Parser for malformed field. Err is one of the package errors.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return "parse " + e.Field + ": " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Parser reads fields of string representation of a challenge, which are separated with ":".
+// It keeps the first error and returns zero values after it, so fields are read one by one and Err is checked once.
+type Parser struct {
+	fields []string
+	err    error
+}
+
+// NewParser returns Parser of data, which must be up to maxSize bytes of count fields,
+// starting with prefix and version. Fields after prefix and version are read by Parser methods.
+func NewParser(data, prefix string, version, count, maxSize int) *Parser {
+	if len(data) > maxSize {
+		return &Parser{err: ErrTooLarge}
+	}
+
+	// extra fields are left in the last part, so data with them has too many parts
+	fields := strings.SplitN(data, separator, count+1)
+	if len(fields) != count || fields[0] != prefix {
+		return &Parser{err: ErrInvalidFormat}
+	}
+
+	if fields[1] != strconv.Itoa(version) {
+		return &Parser{err: &FieldError{Field: "version", Err: ErrInvalidVersion}}
+	}
+
+	return &Parser{fields: fields[2:]}
+}
+
+// Err returns the first error of read fields.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Field returns the next field as is.
+func (p *Parser) Field() string {
+	if p.err != nil || len(p.fields) == 0 {
+		return ""
+	}
+
+	field := p.fields[0]
+	p.fields = p.fields[1:]
+
+	return field
+}
+
+// Int returns the next field, which must be canonical decimal representation of non-negative int.
+func (p *Parser) Int(field string) int {
+	value, ok := ParseUint(p.Field(), MaxUintSize)
+	if !ok || value > int64(int(^uint(0)>>1)) {
+		p.fail(field, ErrInvalidNumber)
+
+		return 0
+	}
+
+	return int(value)
+}
+
+// Date returns the next field, which must be canonical decimal unix time.
+func (p *Parser) Date() int64 {
+	date, ok := ParseUint(p.Field(), MaxDateSize)
+	if !ok {
+		p.fail("date", ErrInvalidDate)
+	}
+
+	return date
+}
+
+// Resource returns the next field, unescaped by UnescapeResource.
+func (p *Parser) Resource() string {
+	resource, err := UnescapeResource(p.Field())
+	if err != nil {
+		p.fail("resource", err)
+	}
+
+	return resource
+}
+
+// Rand returns the next field, which must be valid rand, see ValidateRand.
+func (p *Parser) Rand() string {
+	rand := p.Field()
+	if err := ValidateRand(rand); err != nil {
+		p.fail("rand", err)
+
+		return ""
+	}
+
+	return rand
+}
+
+// fail keeps the first error of the field.
+func (p *Parser) fail(field string, err error) {
+	if p.err == nil {
+		p.err = &FieldError{Field: field, Err: err}
+	}
+}
@@ -0,0 +1,85 @@
+package challenge_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+func TestParser(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			data: "test:1:16:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+		},
+		{
+			name:    "too large",
+			data:    "test:1:16:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=:extra-data-beyond-size-limit",
+			wantErr: challenge.ErrTooLarge,
+		},
+		{
+			name:    "extra field",
+			data:    "test:1:16:1656370862:1:FrZUho0yFjtWiiMonJTt55OFQ9k=:0",
+			wantErr: challenge.ErrInvalidFormat,
+		},
+		{
+			name:    "other prefix",
+			data:    "other:1:16:1656370862:1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+			wantErr: challenge.ErrInvalidFormat,
+		},
+		{
+			name:    "other version",
+			data:    "test:01:16:1656370862:1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+			wantErr: challenge.ErrInvalidVersion,
+		},
+		{
+			name:    "leading zero",
+			data:    "test:1:016:1656370862:1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+			wantErr: challenge.ErrInvalidNumber,
+		},
+		{
+			name:    "not escaped resource",
+			data:    "test:1:16:1656370862:%3a%3a1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+			wantErr: challenge.ErrInvalidResource,
+		},
+		{
+			name:    "negative date",
+			data:    "test:1:16:-1656370862:1:FrZUho0yFjtWiiMonJTt55OFQ9k=",
+			wantErr: challenge.ErrInvalidDate,
+		},
+		{
+			name:    "empty rand",
+			data:    "test:1:16:1656370862:1:",
+			wantErr: challenge.ErrInvalidRand,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			p := challenge.NewParser(tt.data, "test", 1, 6, 64)
+			bits, date, resource, rand := p.Int("bits"), p.Date(), p.Resource(), p.Rand()
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, p.Err(), tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, p.Err())
+			assert.Equal(t, 16, bits)
+			assert.Equal(t, int64(1656370862), date)
+			assert.Equal(t, "::1", resource)
+			assert.Equal(t, "FrZUho0yFjtWiiMonJTt55OFQ9k=", rand)
+		})
+	}
+}
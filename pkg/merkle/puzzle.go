@@ -0,0 +1,311 @@
+package merkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Prefix starts string representation of every puzzle, so it can't be confused with other puzzles.
+const Prefix = "merkle"
+
+// Version is the current puzzle format version.
+const Version = 1
+
+// Limits of puzzle parameters, which protect verifier from large solutions.
+const (
+	MaxHeight = 20 // MaxHeight needs 64 MiB of memory to store the whole tree.
+	MaxProofs = 64
+)
+
+// NodeSize is a size of every tree node.
+const NodeSize = sha256.Size
+
+// MaxPuzzleSize is maximum length of string representation of the puzzle. It fits base64 proofs of the largest puzzle
+// and other fields of allowed size, e.g. resource up to challenge.MaxResourceSize bytes, which may be percent-encoded.
+const MaxPuzzleSize = 1024 + (MaxProofs*MaxHeight*NodeSize+2)/3*4
+
+// puzzleParts is the number of fields of string representation of the puzzle.
+const puzzleParts = 9
+
+// Domain separation tags of hashed data.
+const (
+	leafTag  = 0
+	nodeTag  = 1
+	indexTag = 2
+)
+
+var (
+	ErrInvalidParams   = errors.New("invalid puzzle parameters")
+	ErrInvalidFormat   = challenge.ErrInvalidFormat
+	ErrInvalidResource = challenge.ErrInvalidResource
+	ErrInvalidRand     = challenge.ErrInvalidRand
+)
+
+// DefaultParams need 2^17 hash computations and 4 MiB of memory to build the tree,
+// while verification needs 8 proofs of 16 nodes.
+var DefaultParams = Params{Height: 16, Proofs: 8}
+
+// Params are cost parameters of the puzzle.
+// Client builds a tree with 2^Height leaves and answers Proofs inclusion proofs.
+type Params struct {
+	Height int
+	Proofs int
+}
+
+// Validate checks, that parameters are within limits.
+func (p Params) Validate() error {
+	if p.Height < 1 || p.Height > MaxHeight || p.Proofs < 1 || p.Proofs > MaxProofs {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+// Puzzle is a Merkle tree proof of work: client builds a tree over leaves, seeded by the puzzle,
+// and proves inclusion of leaves with indices, derived from the tree root.
+// Indices are unknown until the whole tree is built, so client can't skip any leaf,
+// while verifier needs only Proofs*Height hash computations.
+type Puzzle struct {
+	params   Params
+	date     int64
+	resource string
+	rand     string
+	root     []byte
+	siblings []byte
+}
+
+// Option configures Puzzle created by New.
+type Option func(p *Puzzle)
+
+// WithRand sets rand field of the puzzle instead of generated random value,
+// e.g. to embed data, which allows issuer to authenticate the puzzle. Rand must be base64 string up to challenge.MaxRandSize.
+func WithRand(rand string) Option {
+	return func(p *Puzzle) {
+		p.rand = rand
+	}
+}
+
+// New returns not solved Puzzle with given resource and parameters. Returns error when parameters are out of limits,
+// resource exceeds challenge.MaxResourceSize, rand is invalid or can't generate random base64 string.
+func New(resource string, params Params, date int64, opts ...Option) (*Puzzle, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := challenge.ValidateResource(resource); err != nil {
+		return nil, err
+	}
+
+	puzzle := &Puzzle{
+		params:   params,
+		date:     date,
+		resource: resource,
+	}
+
+	for _, opt := range opts {
+		opt(puzzle)
+	}
+
+	var err error
+	if puzzle.rand, err = challenge.Rand(puzzle.rand); err != nil {
+		return nil, err
+	}
+
+	return puzzle, nil
+}
+
+// IsPuzzle reports whether data looks like string representation of Merkle tree puzzle.
+func IsPuzzle(data string) bool {
+	return strings.HasPrefix(data, Prefix+":")
+}
+
+// FromString parses data string to Puzzle.
+// String should be formatted as "merkle:version:height:proofs:date:resource:rand:root:siblings",
+// where resource is escaped by challenge.EscapeResource, root and siblings of all proofs are base64 encoded
+// and empty for not solved puzzle. Every field is checked strictly against its format and size limits.
+func FromString(data string) (*Puzzle, error) {
+	p := challenge.NewParser(data, Prefix, Version, puzzleParts, MaxPuzzleSize)
+
+	params := Params{
+		Height: p.Int("height"),
+		Proofs: p.Int("proofs"),
+	}
+
+	puzzle := &Puzzle{
+		params:   params,
+		date:     p.Date(),
+		resource: p.Resource(),
+		rand:     p.Rand(),
+	}
+
+	root, siblings := p.Field(), p.Field()
+
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	if root == "" && siblings == "" {
+		return puzzle, nil
+	}
+
+	var err error
+	if puzzle.root, err = base64.StdEncoding.DecodeString(root); err != nil || len(puzzle.root) != NodeSize {
+		return nil, &challenge.FieldError{Field: "root", Err: ErrInvalidFormat}
+	}
+
+	// size is checked before decoding, so large solutions are rejected cheaply
+	siblingsSize := params.Proofs * params.Height * NodeSize
+	if len(siblings) != base64.StdEncoding.EncodedLen(siblingsSize) {
+		return nil, &challenge.FieldError{Field: "proofs", Err: ErrInvalidFormat}
+	}
+
+	if puzzle.siblings, err = base64.StdEncoding.DecodeString(siblings); err != nil || len(puzzle.siblings) != siblingsSize {
+		return nil, &challenge.FieldError{Field: "proofs", Err: ErrInvalidFormat}
+	}
+
+	return puzzle, nil
+}
+
+// ToString returns string representation of the puzzle, separated with ":".
+func (p *Puzzle) ToString() string {
+	return p.header() + ":" + encodeNodes(p.root) + ":" + encodeNodes(p.siblings)
+}
+
+// Solved reports whether puzzle contains tree root and proofs.
+func (p *Puzzle) Solved() bool {
+	return p.root != nil
+}
+
+// Verify verifies inclusion proofs of all leaves, chosen by the tree root.
+func (p *Puzzle) Verify() bool {
+	if !p.Solved() {
+		return false
+	}
+
+	seed := p.seed()
+	node := make([]byte, 0, NodeSize)
+
+	for proof := 0; proof < p.params.Proofs; proof++ {
+		index := p.index(proof)
+		node = leafHash(node[:0], seed, index)
+
+		for level := 0; level < p.params.Height; level++ {
+			sibling := p.sibling(proof, level)
+
+			if index&1 == 0 {
+				node = nodeHash(node[:0], node, sibling)
+			} else {
+				node = nodeHash(node[:0], sibling, node)
+			}
+
+			index >>= 1
+		}
+
+		if !bytes.Equal(node, p.root) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Puzzle) GetParams() Params {
+	return p.params
+}
+
+func (p *Puzzle) GetDate() int64 {
+	return p.date
+}
+
+func (p *Puzzle) GetResource() string {
+	return p.resource
+}
+
+func (p *Puzzle) GetRandValue() string {
+	return p.rand
+}
+
+// GetRoot returns root of the tree or nil for not solved puzzle.
+func (p *Puzzle) GetRoot() []byte {
+	return append([]byte(nil), p.root...)
+}
+
+// header returns string representation of not solved puzzle without trailing separators.
+func (p *Puzzle) header() string {
+	return fmt.Sprintf(
+		"%s:%d:%d:%d:%d:%s:%s",
+		Prefix, Version, p.params.Height, p.params.Proofs, p.date, challenge.EscapeResource(p.resource), p.rand,
+	)
+}
+
+// seed returns hash of the challenge, which all leaves are derived from.
+func (p *Puzzle) seed() []byte {
+	seed := sha256.Sum256([]byte(p.header()))
+
+	return seed[:]
+}
+
+// index returns index of the leaf, which inclusion is proven by the proof.
+func (p *Puzzle) index(proof int) uint64 {
+	var buf [1 + NodeSize + 4]byte
+
+	buf[0] = indexTag
+	copy(buf[1:], p.root)
+	binary.BigEndian.PutUint32(buf[1+NodeSize:], uint32(proof))
+
+	sum := sha256.Sum256(buf[:])
+
+	return binary.BigEndian.Uint64(sum[:]) & (1<<p.params.Height - 1)
+}
+
+// sibling returns sibling node of the proof on the given level, counting from leaves.
+func (p *Puzzle) sibling(proof, level int) []byte {
+	offset := (proof*p.params.Height + level) * NodeSize
+
+	return p.siblings[offset : offset+NodeSize]
+}
+
+// leafHash appends leaf with given index to dst.
+func leafHash(dst, seed []byte, index uint64) []byte {
+	var buf [1 + NodeSize + 8]byte
+
+	buf[0] = leafTag
+	copy(buf[1:], seed)
+	binary.BigEndian.PutUint64(buf[1+NodeSize:], index)
+
+	sum := sha256.Sum256(buf[:])
+
+	return append(dst, sum[:]...)
+}
+
+// nodeHash appends parent node of given children to dst. Dst may overlap with children.
+func nodeHash(dst, left, right []byte) []byte {
+	var buf [1 + 2*NodeSize]byte
+
+	buf[0] = nodeTag
+	copy(buf[1:], left)
+	copy(buf[1+NodeSize:], right)
+
+	sum := sha256.Sum256(buf[:])
+
+	return append(dst, sum[:]...)
+}
+
+func encodeNodes(nodes []byte) string {
+	if nodes == nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(nodes)
+}
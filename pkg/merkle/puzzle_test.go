@@ -0,0 +1,249 @@
+package merkle_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+)
+
+func TestPuzzle_Solve_Verify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		params  merkle.Params
+		workers int
+	}{
+		{
+			name:    "single leaf pair",
+			params:  merkle.Params{Height: 1, Proofs: 1},
+			workers: 1,
+		},
+		{
+			name:    "split between workers",
+			params:  merkle.Params{Height: 14, Proofs: 8},
+			workers: 4,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := merkle.New("172.21.0.4", tt.params, time.Now().Unix())
+			assert.NoError(t, err)
+			assert.False(t, puzzle.Verify())
+
+			assert.NoError(t, merkle.NewSolver(tt.workers).Solve(context.Background(), puzzle))
+			assert.True(t, puzzle.Solved())
+			assert.True(t, puzzle.Verify())
+
+			parsed, err := merkle.FromString(puzzle.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, puzzle.ToString(), parsed.ToString())
+			assert.Equal(t, puzzle.GetRoot(), parsed.GetRoot())
+			assert.NoError(t, parsed.Validate(challenge.WithMaxAge(time.Minute), challenge.WithClockSkew(0)))
+		})
+	}
+}
+
+func TestPuzzle_Verify_Tampered(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	params := merkle.Params{Height: 4, Proofs: 2}
+
+	puzzle, err := merkle.New("172.21.0.4", params, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, merkle.NewSolver(1).Solve(ctx, puzzle))
+
+	other, err := merkle.New("172.21.0.4", params, puzzle.GetDate())
+	assert.NoError(t, err)
+	assert.NoError(t, merkle.NewSolver(1).Solve(ctx, other))
+
+	parts := strings.Split(puzzle.ToString(), ":")
+	otherParts := strings.Split(other.ToString(), ":")
+
+	// proofs of another tree don't match the root
+	tampered, err := merkle.FromString(strings.Join(append(parts[:8:8], otherParts[8]), ":"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, tampered.Validate(), merkle.ErrInvalidProof)
+
+	// tree of another challenge doesn't contain its leaves
+	tampered, err = merkle.FromString(strings.Join(append(parts[:7:7], otherParts[7:]...), ":"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, tampered.Validate(), merkle.ErrInvalidProof)
+}
+
+func TestPuzzle_Solve_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	puzzle, err := merkle.New("172.21.0.4", merkle.Params{Height: 16, Proofs: 1}, time.Now().Unix())
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, merkle.NewSolver(2).Solve(ctx, puzzle), context.Canceled)
+	assert.False(t, puzzle.Solved())
+}
+
+func TestPuzzle_Validate_Expired(t *testing.T) {
+	t.Parallel()
+
+	puzzle, err := merkle.New("172.21.0.4", merkle.Params{Height: 2, Proofs: 1}, 1656370862)
+	assert.NoError(t, err)
+	assert.NoError(t, merkle.NewSolver(1).Solve(context.Background(), puzzle))
+
+	assert.ErrorIs(t, puzzle.Validate(challenge.WithMaxAge(time.Minute)), challenge.ErrExpired)
+}
+
+func TestNew_IPv6(t *testing.T) {
+	t.Parallel()
+
+	puzzle, err := merkle.New("::1", merkle.Params{Height: 4, Proofs: 2}, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, merkle.NewSolver(1).Solve(context.Background(), puzzle))
+
+	parsed, err := merkle.FromString(puzzle.ToString())
+	assert.NoError(t, err)
+	assert.Equal(t, "::1", parsed.GetResource())
+	assert.True(t, parsed.Verify())
+
+	_, err = merkle.New("::1", merkle.Params{Height: 4, Proofs: 2}, time.Now().Unix(), merkle.WithRand("a:b"))
+	assert.ErrorIs(t, err, merkle.ErrInvalidRand)
+}
+
+func TestFromString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "challenge",
+			data: "merkle:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+		},
+		{
+			name: "solution",
+			data: "merkle:1:1:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" +
+				"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=",
+		},
+		{
+			name: "escaped IPv6 resource",
+			data: "merkle:1:16:8:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+		},
+		{
+			name:    "not escaped IPv6 resource",
+			data:    "merkle:1:16:8:1656370862:::1:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+		{
+			name:    "resource exceeds limit",
+			data:    "merkle:1:16:8:1656370862:" + strings.Repeat("r", 257) + ":FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+		{
+			name:    "puzzle exceeds limit",
+			data:    "merkle:1:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::" + strings.Repeat("A", merkle.MaxPuzzleSize),
+			wantErr: true,
+		},
+		{
+			name:    "not canonical height",
+			data:    "merkle:1:016:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+		{
+			name:    "rand is not base64",
+			data:    "merkle:1:16:8:1656370862:172.21.0.4:rand?::",
+			wantErr: true,
+		},
+		{
+			name:    "height exceeds limit",
+			data:    "merkle:1:21:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+		{
+			name:    "no proofs",
+			data:    "merkle:1:16:0:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+		{
+			name:    "root without proofs",
+			data:    "merkle:1:1:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:",
+			wantErr: true,
+		},
+		{
+			name: "extra proof nodes",
+			data: "merkle:1:1:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=:" +
+				"AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==",
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			data:    "merkle:2:16:8:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=::",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := merkle.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.data, puzzle.ToString())
+			}
+		})
+	}
+}
+
+func TestFromString_InvalidNodes(t *testing.T) {
+	t.Parallel()
+
+	header := "merkle:1:1:1:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k="
+	node := "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "root is not base64",
+			data: header + ":root?:" + node,
+		},
+		{
+			name: "short root",
+			data: header + ":AAAA:" + node,
+		},
+		{
+			name: "proofs of wrong size",
+			data: header + ":" + node + ":AAAA",
+		},
+		{
+			name: "proofs are not base64",
+			data: header + ":" + node + ":" + strings.Repeat("?", len(node)),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := merkle.FromString(tt.data)
+			assert.ErrorIs(t, err, merkle.ErrInvalidFormat)
+		})
+	}
+}
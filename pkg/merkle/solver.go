@@ -0,0 +1,119 @@
+package merkle
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// checkInterval is a number of nodes, computed by worker between context checks.
+const checkInterval = 1 << 12
+
+// Solver builds Merkle tree of the puzzle in parallel, splitting every tree level between workers.
+// Memory usage is 2^(Height+1)*NodeSize bytes.
+type Solver struct {
+	workers int
+}
+
+// NewSolver returns Solver with given workers count. Uses runtime.NumCPU() workers when count is not positive.
+func NewSolver(workers int) *Solver {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	return &Solver{
+		workers: workers,
+	}
+}
+
+// Solve builds the tree and sets its root and inclusion proofs of leaves, chosen by the root, to the puzzle.
+// Partially built tree is dropped, when ctx is done before the root is computed, and ctx.Err() is returned.
+func (s *Solver) Solve(ctx context.Context, puzzle *Puzzle) error {
+	height := puzzle.params.Height
+	seed := puzzle.seed()
+
+	// levels[0] contains leaves and levels[height] contains only root, nodes of every level are stored sequentially
+	levels := make([][]byte, height+1)
+	levels[0] = make([]byte, (1<<height)*NodeSize)
+
+	// nodes are appended to empty slices of the level, so they are written in place
+	err := s.parallel(ctx, 1<<height, func(from, to int) {
+		for i := from; i < to; i++ {
+			leafHash(levels[0][i*NodeSize:i*NodeSize], seed, uint64(i))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	for level := 1; level <= height; level++ {
+		children := levels[level-1]
+		nodes := make([]byte, len(children)/2)
+
+		err := s.parallel(ctx, len(nodes)/NodeSize, func(from, to int) {
+			for i := from; i < to; i++ {
+				left := children[2*i*NodeSize : (2*i+1)*NodeSize]
+				right := children[(2*i+1)*NodeSize : (2*i+2)*NodeSize]
+
+				nodeHash(nodes[i*NodeSize:i*NodeSize], left, right)
+			}
+		})
+		if err != nil {
+			return err
+		}
+
+		levels[level] = nodes
+	}
+
+	puzzle.root = levels[height]
+	puzzle.siblings = make([]byte, 0, puzzle.params.Proofs*height*NodeSize)
+
+	for proof := 0; proof < puzzle.params.Proofs; proof++ {
+		index := puzzle.index(proof)
+
+		for level := 0; level < height; level++ {
+			sibling := (index ^ 1) * NodeSize
+			puzzle.siblings = append(puzzle.siblings, levels[level][sibling:sibling+NodeSize]...)
+			index >>= 1
+		}
+	}
+
+	return nil
+}
+
+// parallel calls fn for ranges of [0, count), split between workers, and waits for all of them.
+// Every worker gets at least checkInterval nodes, so upper levels of the tree are not split.
+func (s *Solver) parallel(ctx context.Context, count int, fn func(from, to int)) error {
+	var wg sync.WaitGroup
+
+	chunk := (count + s.workers - 1) / s.workers
+	if chunk < checkInterval {
+		chunk = checkInterval
+	}
+
+	for from := 0; from < count; from += chunk {
+		to := from + chunk
+		if to > count {
+			to = count
+		}
+
+		wg.Add(1)
+
+		go func(from, to int) {
+			defer wg.Done()
+
+			for ; from < to && ctx.Err() == nil; from += checkInterval {
+				end := from + checkInterval
+				if end > to {
+					end = to
+				}
+
+				fn(from, end)
+			}
+		}(from, to)
+	}
+
+	wg.Wait()
+
+	return ctx.Err()
+}
@@ -0,0 +1,23 @@
+package merkle
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var ErrInvalidProof = errors.New("puzzle proofs are missing or invalid")
+
+// Validate checks puzzle date with the options, and then root and proofs of the solution.
+// Returns date error of the options or ErrInvalidProof.
+func (p *Puzzle) Validate(opts ...challenge.DateOption) error {
+	if err := challenge.ValidateDate(p.date, opts...); err != nil {
+		return err
+	}
+
+	if !p.Verify() {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
@@ -0,0 +1,359 @@
+package protocol
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Version is a wire format of messages.
+type Version int
+
+const (
+	// VersionDetect makes Decoder detect version by the first byte of the stream.
+	VersionDetect Version = 0
+	// V1 is a text format: type and body separated by '|' and terminated by '\n'. Body can't contain '\n'.
+	V1 Version = 1
+	// V2 is a binary format: every message is a frame of 4 bytes big-endian payload length followed by payload,
+	// which is uvarint length of the type, the type and the body. Body may contain any bytes.
+	V2 Version = 2
+	// V3 is V2 frame, which payload has message ID and headers between the type and the body: uvarint length of ID,
+	// the ID, uvarint count of headers and uvarint length prefixed key and value of every header.
+	// It's never detected by Decoder, so it must be negotiated by hello messages.
+	V3 Version = 3
+)
+
+// DefaultMaxFrameSize limits size of a single message, unless it's set by WithMaxFrameSize.
+const DefaultMaxFrameSize = 64 << 10
+
+// MaxFrameSize is the largest allowed frame size. Length prefix of smaller V2 frames starts with zero byte,
+// which never starts V1 messages, so Decoder detects version by the first byte.
+const MaxFrameSize = 1<<24 - 1
+
+// frameHeaderSize is the size of V2 frame length prefix.
+const frameHeaderSize = 4
+
+var (
+	ErrFrameTooLarge      = errors.New("frame is too large")
+	ErrInvalidFrame       = errors.New("invalid frame")
+	ErrUnsupportedVersion = errors.New("unsupported protocol version")
+)
+
+// CodecOption configures Encoder and Decoder.
+type CodecOption func(o *codecOptions)
+
+type codecOptions struct {
+	version      Version
+	maxFrameSize int
+}
+
+// WithVersion sets wire format version. Encoder uses V2 by default, Decoder detects version of the stream.
+func WithVersion(version Version) CodecOption {
+	return func(o *codecOptions) {
+		o.version = version
+	}
+}
+
+// WithMaxFrameSize sets maximum size of encoded message, including V2 frame header or V1 line terminator.
+// Size is capped by MaxFrameSize, DefaultMaxFrameSize is used, when size is not positive.
+func WithMaxFrameSize(size int) CodecOption {
+	return func(o *codecOptions) {
+		o.maxFrameSize = size
+	}
+}
+
+func newCodecOptions(version Version, opts []CodecOption) codecOptions {
+	options := codecOptions{
+		version:      version,
+		maxFrameSize: DefaultMaxFrameSize,
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	switch {
+	case options.maxFrameSize <= 0:
+		options.maxFrameSize = DefaultMaxFrameSize
+	case options.maxFrameSize > MaxFrameSize:
+		options.maxFrameSize = MaxFrameSize
+	}
+
+	return options
+}
+
+// Decoder reads messages from a stream. It buffers the stream, so single Decoder should be used
+// for all messages of a connection.
+type Decoder struct {
+	reader  *bufio.Reader
+	options codecOptions
+}
+
+// NewDecoder returns Decoder of messages from r.
+func NewDecoder(r io.Reader, opts ...CodecOption) *Decoder {
+	return &Decoder{
+		reader:  bufio.NewReader(r),
+		options: newCodecOptions(VersionDetect, opts),
+	}
+}
+
+// Version returns version of the stream. It's VersionDetect until the first message is decoded.
+func (d *Decoder) Version() Version {
+	return d.options.version
+}
+
+// SetVersion switches version of the following messages, e.g. to the version negotiated by hello messages.
+func (d *Decoder) SetVersion(version Version) {
+	d.options.version = version
+}
+
+// Decode reads the next message. Returns ErrFrameTooLarge, when message exceeds maximum frame size,
+// and io.EOF, when stream ends between messages.
+func (d *Decoder) Decode() (Message, error) {
+	if d.options.version == VersionDetect {
+		first, err := d.reader.Peek(1)
+		if err != nil {
+			return Message{}, err
+		}
+
+		d.options.version = V1
+		if first[0] == 0 {
+			d.options.version = V2
+		}
+	}
+
+	switch d.options.version { //nolint:exhaustive // version is already detected
+	case V1:
+		return d.decodeText()
+	case V2, V3:
+		return d.decodeFrame()
+	default:
+		return Message{}, ErrUnsupportedVersion
+	}
+}
+
+func (d *Decoder) decodeText() (Message, error) {
+	var line []byte
+
+	for {
+		chunk, err := d.reader.ReadSlice('\n')
+		line = append(line, chunk...)
+
+		if len(line) > d.options.maxFrameSize {
+			return Message{}, ErrFrameTooLarge
+		}
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+
+		if errors.Is(err, io.EOF) && len(line) > 0 {
+			return Message{}, io.ErrUnexpectedEOF
+		}
+
+		if err != nil {
+			return Message{}, err
+		}
+
+		break
+	}
+
+	messageType, body, _ := strings.Cut(strings.TrimSuffix(string(line), "\n"), delimiter)
+
+	return NewMessage(convertMessageType(messageType), body), nil
+}
+
+func (d *Decoder) decodeFrame() (Message, error) {
+	var header [frameHeaderSize]byte
+
+	if _, err := io.ReadFull(d.reader, header[:]); err != nil {
+		return Message{}, err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size)+frameHeaderSize > uint64(d.options.maxFrameSize) {
+		return Message{}, ErrFrameTooLarge
+	}
+
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(d.reader, payload); err != nil {
+		return Message{}, unexpectedEOF(err)
+	}
+
+	r := payloadReader{payload: payload}
+	msg := NewMessage(convertMessageType(r.field()), "")
+
+	if d.options.version == V3 {
+		msg.id = r.field()
+
+		// every header takes at least two bytes, so count can't exceed half of the rest payload
+		count := r.uvarint()
+		if count > uint64(len(r.payload)/2) {
+			return Message{}, ErrInvalidFrame
+		}
+
+		if count > 0 {
+			msg.headers = make(map[string]string, count)
+		}
+
+		for i := uint64(0); i < count; i++ {
+			key := r.field()
+			msg.headers[key] = r.field()
+		}
+	}
+
+	if r.err != nil {
+		return Message{}, ErrInvalidFrame
+	}
+
+	msg.body = string(r.payload)
+
+	return msg, nil
+}
+
+// payloadReader reads uvarint length prefixed fields of frame payload. After the first error it reads only empty fields.
+type payloadReader struct {
+	payload []byte
+	err     error
+}
+
+func (r *payloadReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+
+	value, n := binary.Uvarint(r.payload)
+	if n <= 0 {
+		r.err = ErrInvalidFrame
+
+		return 0
+	}
+
+	r.payload = r.payload[n:]
+
+	return value
+}
+
+func (r *payloadReader) field() string {
+	size := r.uvarint()
+	if r.err != nil {
+		return ""
+	}
+
+	if size > uint64(len(r.payload)) {
+		r.err = ErrInvalidFrame
+
+		return ""
+	}
+
+	field := string(r.payload[:size])
+	r.payload = r.payload[size:]
+
+	return field
+}
+
+// Encoder writes messages to a stream.
+type Encoder struct {
+	writer  io.Writer
+	options codecOptions
+}
+
+// NewEncoder returns Encoder of messages to w. Every message is written with a single Write call.
+func NewEncoder(w io.Writer, opts ...CodecOption) *Encoder {
+	return &Encoder{
+		writer:  w,
+		options: newCodecOptions(V2, opts),
+	}
+}
+
+// Version returns version of encoded messages.
+func (e *Encoder) Version() Version {
+	return e.options.version
+}
+
+// SetVersion switches version of the following messages, e.g. to the version negotiated by hello messages.
+func (e *Encoder) SetVersion(version Version) {
+	e.options.version = version
+}
+
+// Encode writes the message. Returns ErrFrameTooLarge, when encoded message exceeds maximum frame size,
+// and ErrInvalidFrame, when message can't be represented in V1 format. Message ID and headers are written only in V3.
+func (e *Encoder) Encode(msg Message) error {
+	var data []byte
+
+	switch e.options.version { //nolint:exhaustive // encoder can't detect version
+	case V1:
+		if strings.Contains(msg.ToString(), "\n") {
+			return ErrInvalidFrame
+		}
+
+		data = []byte(msg.ToString() + "\n")
+	case V2, V3:
+		data = encodeFrame(msg, e.options.version)
+	default:
+		return ErrUnsupportedVersion
+	}
+
+	if len(data) > e.options.maxFrameSize {
+		return ErrFrameTooLarge
+	}
+
+	if _, err := e.writer.Write(data); err != nil {
+		return fmt.Errorf("write message: %w", err)
+	}
+
+	return nil
+}
+
+func encodeFrame(msg Message, version Version) []byte {
+	payload := appendField(nil, string(msg.messageType))
+
+	if version == V3 {
+		payload = appendField(payload, msg.id)
+		payload = appendUvarint(payload, uint64(len(msg.headers)))
+
+		// headers are sorted, so the same message is always encoded the same way
+		keys := make([]string, 0, len(msg.headers))
+		for key := range msg.headers {
+			keys = append(keys, key)
+		}
+
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			payload = appendField(payload, key)
+			payload = appendField(payload, msg.headers[key])
+		}
+	}
+
+	payload = append(payload, msg.body...)
+
+	data := make([]byte, frameHeaderSize, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(data, uint32(len(payload)))
+
+	return append(data, payload...)
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+
+	n := binary.PutUvarint(buf[:], value)
+
+	return append(data, buf[:n]...)
+}
+
+func appendField(data []byte, field string) []byte {
+	return append(appendUvarint(data, uint64(len(field))), field...)
+}
+
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
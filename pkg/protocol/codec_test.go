@@ -0,0 +1,207 @@
+package protocol_test
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
+)
+
+func TestEncoder_Decoder(t *testing.T) {
+	t.Parallel()
+
+	messages := []protocol.Message{
+		protocol.NewMessage(protocol.TypeChallenge, ""),
+		protocol.NewMessage(protocol.TypeChallenge, "hashcash:3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:0"),
+		protocol.NewMessage(protocol.TypeResource, "quote with | delimiter"),
+	}
+
+	tests := []struct {
+		name       string
+		version    protocol.Version
+		negotiated bool
+		messages   []protocol.Message
+	}{
+		{
+			name:     "v1",
+			version:  protocol.V1,
+			messages: messages,
+		},
+		{
+			name:    "v2",
+			version: protocol.V2,
+			messages: append(messages,
+				protocol.NewMessage(protocol.TypeResource, "multiline\nquote"),
+				protocol.NewMessage(protocol.TypeResource, strings.Repeat("q", 5000)),
+			),
+		},
+		{
+			name:       "v3",
+			version:    protocol.V3,
+			negotiated: true,
+			messages: append(messages,
+				protocol.NewMessage(protocol.TypeChallenge, "").WithID("1"),
+				protocol.NewMessage(protocol.TypeResource, "multiline\nquote").WithID("2").
+					WithHeader("trace", "abc").
+					WithHeader("", "empty key"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			encoder := protocol.NewEncoder(&buf, protocol.WithVersion(tt.version))
+			for _, msg := range tt.messages {
+				assert.NoError(t, encoder.Encode(msg))
+			}
+
+			// all messages are buffered at once, so decoder must keep bytes of the following messages
+			var opts []protocol.CodecOption
+			if tt.negotiated {
+				opts = append(opts, protocol.WithVersion(tt.version))
+			}
+
+			decoder := protocol.NewDecoder(&buf, opts...)
+			for _, want := range tt.messages {
+				got, err := decoder.Decode()
+				assert.NoError(t, err)
+				assert.Equal(t, want, got)
+			}
+
+			assert.Equal(t, tt.version, decoder.Version())
+
+			_, err := decoder.Decode()
+			assert.ErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestDecoder_Decode_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		opts    []protocol.CodecOption
+		wantErr error
+	}{
+		{
+			name:    "v1 line too long",
+			data:    "resource|" + strings.Repeat("q", 100) + "\n",
+			opts:    []protocol.CodecOption{protocol.WithMaxFrameSize(64)},
+			wantErr: protocol.ErrFrameTooLarge,
+		},
+		{
+			name:    "v1 without line end",
+			data:    "challenge|test challenge",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "v2 frame too large",
+			data:    "\x00\x00\x00\x40resource",
+			opts:    []protocol.CodecOption{protocol.WithMaxFrameSize(64)},
+			wantErr: protocol.ErrFrameTooLarge,
+		},
+		{
+			name:    "v2 truncated frame",
+			data:    "\x00\x00\x00\x10\x08resource",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "v2 truncated header",
+			data:    "\x00\x00",
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "v2 type size exceeds frame",
+			data:    "\x00\x00\x00\x03\x08re",
+			wantErr: protocol.ErrInvalidFrame,
+		},
+		{
+			name:    "v3 headers count exceeds frame",
+			data:    "\x00\x00\x00\x04\x01r\x00\x7f",
+			opts:    []protocol.CodecOption{protocol.WithVersion(protocol.V3)},
+			wantErr: protocol.ErrInvalidFrame,
+		},
+		{
+			name:    "v3 header value exceeds frame",
+			data:    "\x00\x00\x00\x09\x01r\x00\x01\x01k\x05ab",
+			opts:    []protocol.CodecOption{protocol.WithVersion(protocol.V3)},
+			wantErr: protocol.ErrInvalidFrame,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := protocol.NewDecoder(strings.NewReader(tt.data), tt.opts...).Decode()
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestEncoder_Encode_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		msg     protocol.Message
+		opts    []protocol.CodecOption
+		wantErr error
+	}{
+		{
+			name:    "v1 body with line end",
+			msg:     protocol.NewMessage(protocol.TypeResource, "multiline\nquote"),
+			opts:    []protocol.CodecOption{protocol.WithVersion(protocol.V1)},
+			wantErr: protocol.ErrInvalidFrame,
+		},
+		{
+			name:    "frame too large",
+			msg:     protocol.NewMessage(protocol.TypeResource, strings.Repeat("q", 100)),
+			opts:    []protocol.CodecOption{protocol.WithMaxFrameSize(64)},
+			wantErr: protocol.ErrFrameTooLarge,
+		},
+		{
+			name:    "unknown version",
+			msg:     protocol.NewMessage(protocol.TypeResource, "quote"),
+			opts:    []protocol.CodecOption{protocol.WithVersion(4)},
+			wantErr: protocol.ErrUnsupportedVersion,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+
+			assert.ErrorIs(t, protocol.NewEncoder(&buf, tt.opts...).Encode(tt.msg), tt.wantErr)
+			assert.Zero(t, buf.Len())
+		})
+	}
+}
+
+func TestEncoder_Encode_DropsMetadata(t *testing.T) {
+	t.Parallel()
+
+	msg := protocol.NewMessage(protocol.TypeResource, "quote").WithID("1").WithHeader("trace", "abc")
+
+	for _, version := range []protocol.Version{protocol.V1, protocol.V2} {
+		var buf bytes.Buffer
+
+		assert.NoError(t, protocol.NewEncoder(&buf, protocol.WithVersion(version)).Encode(msg))
+
+		got, err := protocol.NewDecoder(&buf).Decode()
+		assert.NoError(t, err)
+		assert.Equal(t, protocol.NewMessage(protocol.TypeResource, "quote"), got)
+	}
+}
@@ -0,0 +1,73 @@
+package protocol
+
+import (
+	"encoding/json"
+)
+
+// ErrorCode is a machine-readable reason of error message.
+type ErrorCode string
+
+const (
+	CodeInvalidFormat    ErrorCode = "invalid_format"    // CodeInvalidFormat is sent, when message or challenge is malformed.
+	CodeChallengeExpired ErrorCode = "challenge_expired" // CodeChallengeExpired is sent, when challenge is expired or not issued.
+	CodeInvalidSolution  ErrorCode = "invalid_solution"  // CodeInvalidSolution is sent, when challenge is not solved or already used.
+	CodeRateLimited      ErrorCode = "rate_limited"      // CodeRateLimited is sent, when client exceeds limits of the server.
+	CodeInternal         ErrorCode = "internal"          // CodeInternal is sent, when request fails for reasons unrelated to client.
+	CodeUnknown          ErrorCode = "unknown"           // CodeUnknown is set for error messages of peers without error codes.
+)
+
+// Sentinel errors, which match Error of the same code with errors.Is.
+var (
+	ErrInvalidFormat    = &Error{Code: CodeInvalidFormat}
+	ErrChallengeExpired = &Error{Code: CodeChallengeExpired}
+	ErrInvalidSolution  = &Error{Code: CodeInvalidSolution}
+	ErrRateLimited      = &Error{Code: CodeRateLimited}
+	ErrInternal         = &Error{Code: CodeInternal}
+)
+
+// Error is a body of error message: error code and human-readable description, which never contains internal details.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message,omitempty"`
+}
+
+// NewError returns Error with given code and description.
+func NewError(code ErrorCode, message string) *Error {
+	return &Error{
+		Code:    code,
+		Message: message,
+	}
+}
+
+// ParseError returns Error of error message. Body of peers without error codes is returned as message of CodeUnknown.
+func ParseError(msg Message) *Error {
+	var e Error
+	if err := json.Unmarshal([]byte(msg.GetBody()), &e); err != nil || e.Code == "" {
+		return NewError(CodeUnknown, msg.GetBody())
+	}
+
+	return &e
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return string(e.Code)
+	}
+
+	return string(e.Code) + ": " + e.Message
+}
+
+// Is reports whether target is Error with the same code.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+
+	return ok && t.Code == e.Code
+}
+
+// ToMessage returns error message with the error as body.
+func (e *Error) ToMessage() Message {
+	// marshaling of struct with string fields never fails
+	body, _ := json.Marshal(e)
+
+	return NewMessage(TypeErr, string(body))
+}
@@ -0,0 +1,61 @@
+package protocol_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
+)
+
+func TestParseError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		msg  protocol.Message
+		want *protocol.Error
+	}{
+		{
+			name: "round trip",
+			msg:  protocol.NewError(protocol.CodeChallengeExpired, "stamp is expired").ToMessage(),
+			want: protocol.NewError(protocol.CodeChallengeExpired, "stamp is expired"),
+		},
+		{
+			name: "without message",
+			msg:  protocol.ErrInternal.ToMessage(),
+			want: protocol.NewError(protocol.CodeInternal, ""),
+		},
+		{
+			name: "peer without codes",
+			msg:  protocol.NewMessage(protocol.TypeErr, "invalid pow"),
+			want: protocol.NewError(protocol.CodeUnknown, "invalid pow"),
+		},
+		{
+			name: "json without code",
+			msg:  protocol.NewMessage(protocol.TypeErr, `{"message":"invalid pow"}`),
+			want: protocol.NewError(protocol.CodeUnknown, `{"message":"invalid pow"}`),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, protocol.ParseError(tt.msg))
+		})
+	}
+}
+
+func TestError_Is(t *testing.T) {
+	t.Parallel()
+
+	err := protocol.ParseError(protocol.NewError(protocol.CodeRateLimited, "too many open challenges").ToMessage())
+
+	assert.ErrorIs(t, err, protocol.ErrRateLimited)
+	assert.ErrorIs(t, fmt.Errorf("handshake: %w", err), protocol.ErrRateLimited)
+	assert.NotErrorIs(t, err, protocol.ErrInternal)
+	assert.Equal(t, "rate_limited: too many open challenges", err.Error())
+	assert.Equal(t, protocol.TypeErr, err.ToMessage().GetType())
+}
@@ -0,0 +1,112 @@
+package protocol
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// EncodingText is encoding of challenges and solutions as strings of their puzzles, e.g. "hashcash:3:sha256:...".
+const EncodingText = "text"
+
+// HelloVersion is the version, which hello messages are always sent with, so peers of any version can read them.
+const HelloVersion = V1
+
+var (
+	ErrIncompatible = errors.New("peers are incompatible")
+	ErrInvalidHello = errors.New("invalid hello message")
+	errNoVersion    = errors.New("no common protocol version")
+	errNoPuzzle     = errors.New("no common puzzle")
+	errNoEncoding   = errors.New("no common encoding")
+	errNotHello     = errors.New("message is not hello")
+)
+
+// Capabilities are protocol versions, puzzle types and challenge encodings, which peer supports,
+// ordered by peer preference. Client sends its capabilities in hello message, and server replies
+// with the chosen ones: single version and supported subsets of puzzles and encodings.
+// Unknown fields of hello messages are ignored, so new capabilities can be added without breaking older peers.
+// EncodingText is the only encoding so far, so Encodings are exchanged and checked,
+// but negotiation doesn't change anything until a second encoding exists.
+type Capabilities struct {
+	Versions  []Version `json:"versions"`
+	Puzzles   []string  `json:"puzzles"`
+	Encodings []string  `json:"encodings"`
+}
+
+// NewHello returns hello message with the capabilities.
+func NewHello(capabilities Capabilities) (Message, error) {
+	body, err := json.Marshal(capabilities)
+	if err != nil {
+		return Message{}, err
+	}
+
+	return NewMessage(TypeHello, string(body)), nil
+}
+
+// ParseHello returns capabilities of hello message. Returns ErrInvalidHello, when message is not hello or malformed.
+func ParseHello(msg Message) (Capabilities, error) {
+	if msg.GetType() != TypeHello {
+		return Capabilities{}, fmt.Errorf("%w: %s", ErrInvalidHello, errNotHello)
+	}
+
+	var capabilities Capabilities
+	if err := json.Unmarshal([]byte(msg.GetBody()), &capabilities); err != nil {
+		return Capabilities{}, fmt.Errorf("%w: %s", ErrInvalidHello, err)
+	}
+
+	return capabilities, nil
+}
+
+// Negotiate chooses capabilities, supported by both c and peer, in order of c preference:
+// the first common version and all common puzzles and encodings.
+// Returns error wrapping ErrIncompatible, when peers have no common version, puzzle or encoding.
+func (c Capabilities) Negotiate(peer Capabilities) (Capabilities, error) {
+	versions := intersect(c.Versions, peer.Versions)
+	if len(versions) == 0 {
+		return Capabilities{}, fmt.Errorf("%w: %s", ErrIncompatible, errNoVersion)
+	}
+
+	chosen := Capabilities{
+		Versions:  versions[:1],
+		Puzzles:   intersect(c.Puzzles, peer.Puzzles),
+		Encodings: intersect(c.Encodings, peer.Encodings),
+	}
+
+	if len(chosen.Puzzles) == 0 {
+		return Capabilities{}, fmt.Errorf("%w: %s", ErrIncompatible, errNoPuzzle)
+	}
+
+	if len(chosen.Encodings) == 0 {
+		return Capabilities{}, fmt.Errorf("%w: %s", ErrIncompatible, errNoEncoding)
+	}
+
+	return chosen, nil
+}
+
+// Version returns the most preferred version of capabilities, which is the chosen version of negotiated ones.
+func (c Capabilities) Version() Version {
+	if len(c.Versions) == 0 {
+		return VersionDetect
+	}
+
+	return c.Versions[0]
+}
+
+// intersect returns values of preferred, which are present in other, keeping order of preferred.
+func intersect[T comparable](preferred, other []T) []T {
+	set := make(map[T]struct{}, len(other))
+	for _, value := range other {
+		set[value] = struct{}{}
+	}
+
+	var common []T
+
+	for _, value := range preferred {
+		if _, ok := set[value]; ok {
+			common = append(common, value)
+			delete(set, value)
+		}
+	}
+
+	return common
+}
@@ -0,0 +1,125 @@
+package protocol_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/protocol"
+)
+
+func TestCapabilities_Negotiate(t *testing.T) {
+	t.Parallel()
+
+	server := protocol.Capabilities{
+		Versions:  []protocol.Version{protocol.V2, protocol.V1},
+		Puzzles:   []string{"hashcash", "balloon"},
+		Encodings: []string{protocol.EncodingText},
+	}
+
+	tests := []struct {
+		name    string
+		client  protocol.Capabilities
+		want    protocol.Capabilities
+		wantErr error
+	}{
+		{
+			name: "server preference",
+			client: protocol.Capabilities{
+				Versions:  []protocol.Version{protocol.V1, protocol.V2},
+				Puzzles:   []string{"merkle", "balloon", "hashcash"},
+				Encodings: []string{"binary", protocol.EncodingText},
+			},
+			want: protocol.Capabilities{
+				Versions:  []protocol.Version{protocol.V2},
+				Puzzles:   []string{"hashcash", "balloon"},
+				Encodings: []string{protocol.EncodingText},
+			},
+		},
+		{
+			name: "older client",
+			client: protocol.Capabilities{
+				Versions:  []protocol.Version{protocol.V1},
+				Puzzles:   []string{"hashcash"},
+				Encodings: []string{protocol.EncodingText},
+			},
+			want: protocol.Capabilities{
+				Versions:  []protocol.Version{protocol.V1},
+				Puzzles:   []string{"hashcash"},
+				Encodings: []string{protocol.EncodingText},
+			},
+		},
+		{
+			name: "no common version",
+			client: protocol.Capabilities{
+				Versions:  []protocol.Version{3},
+				Puzzles:   []string{"hashcash"},
+				Encodings: []string{protocol.EncodingText},
+			},
+			wantErr: protocol.ErrIncompatible,
+		},
+		{
+			name: "no common puzzle",
+			client: protocol.Capabilities{
+				Versions:  []protocol.Version{protocol.V2},
+				Puzzles:   []string{"vdf"},
+				Encodings: []string{protocol.EncodingText},
+			},
+			wantErr: protocol.ErrIncompatible,
+		},
+		{
+			name: "no encodings",
+			client: protocol.Capabilities{
+				Versions: []protocol.Version{protocol.V2},
+				Puzzles:  []string{"hashcash"},
+			},
+			wantErr: protocol.ErrIncompatible,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := server.Negotiate(tt.client)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want.Versions[0], got.Version())
+		})
+	}
+}
+
+func TestHello(t *testing.T) {
+	t.Parallel()
+
+	capabilities := protocol.Capabilities{
+		Versions:  []protocol.Version{protocol.V2, protocol.V1},
+		Puzzles:   []string{"hashcash"},
+		Encodings: []string{protocol.EncodingText},
+	}
+
+	msg, err := protocol.NewHello(capabilities)
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.TypeHello, msg.GetType())
+
+	got, err := protocol.ParseHello(msg)
+	assert.NoError(t, err)
+	assert.Equal(t, capabilities, got)
+
+	// fields of newer peers are ignored
+	got, err = protocol.ParseHello(protocol.NewMessage(protocol.TypeHello, `{"versions":[2],"puzzles":["hashcash"],"compression":["gzip"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, protocol.Capabilities{Versions: []protocol.Version{protocol.V2}, Puzzles: []string{"hashcash"}}, got)
+
+	_, err = protocol.ParseHello(protocol.NewMessage(protocol.TypeHello, "versions=2"))
+	assert.ErrorIs(t, err, protocol.ErrInvalidHello)
+
+	_, err = protocol.ParseHello(protocol.NewMessage(protocol.TypeChallenge, ""))
+	assert.ErrorIs(t, err, protocol.ErrInvalidHello)
+}
@@ -13,17 +13,23 @@ type Type string
 const (
 	TypeChallenge Type = "challenge" // TypeChallenge is sent by server, when pass challenge, and by client, when client wants to be challenged.
 	TypeResource  Type = "resource"  // TypeResource is sent by server when send resource, and by client, when client passed the challege.
+	TypeGuide     Type = "guide"     // TypeGuide is sent by client with guided tour to visit its next guide, and by server with extended tour.
+	TypeHello     Type = "hello"     // TypeHello is sent by client with its capabilities before other messages, and by server with the chosen ones.
 	TypeErr       Type = "error"     // TypeResource is sent by server and client when smth went wrong.
 )
 
-// Delimiter between type and body.
+// Delimiter between type and body of V1 messages.
 const delimiter = "|"
 
 // Message is a struct for communication between client and server.
 // Contains message type for determine, how this message should be understood, and body with message payload.
+// Optional ID and headers are sent only in V3 format, server copies them from request to its response,
+// so client can send several requests without waiting for responses and match responses by ID.
 type Message struct {
 	messageType Type
 	body        string
+	id          string
+	headers     map[string]string
 }
 
 // NewMessage creates Message from message type and body
@@ -35,6 +41,9 @@ func NewMessage(messageType Type, body string) Message {
 }
 
 // ParseFromReader parses string until \n from reader and tries to create Message from it.
+// It reads through a new buffer on every call, so bytes after the message are lost.
+//
+// Deprecated: use Decoder, which keeps buffered bytes between messages and supports V2 frames.
 func ParseFromReader(rd io.Reader) (Message, error) {
 	reader := bufio.NewReader(rd)
 
@@ -72,6 +81,49 @@ func (m Message) GetBody() string {
 	return m.body
 }
 
+// GetID returns message ID, which is empty, when it's not set.
+func (m Message) GetID() string {
+	return m.id
+}
+
+// GetHeaders returns metadata headers of the message. Returned map must not be modified.
+func (m Message) GetHeaders() map[string]string {
+	return m.headers
+}
+
+// GetHeader returns value of metadata header or empty string, when header is not set.
+func (m Message) GetHeader(key string) string {
+	return m.headers[key]
+}
+
+// WithID returns copy of the message with given ID.
+func (m Message) WithID(id string) Message {
+	m.id = id
+
+	return m
+}
+
+// WithHeader returns copy of the message with metadata header set to value.
+func (m Message) WithHeader(key, value string) Message {
+	headers := make(map[string]string, len(m.headers)+1)
+	for k, v := range m.headers {
+		headers[k] = v
+	}
+
+	headers[key] = value
+	m.headers = headers
+
+	return m
+}
+
+// InReplyTo returns copy of the message with ID and headers of request, so the message can be matched with request.
+func (m Message) InReplyTo(request Message) Message {
+	m.id = request.id
+	m.headers = request.headers
+
+	return m
+}
+
 // ToString returns string view of message separated by delimiter. When body is empty, just returns message type.
 func (m Message) ToString() string {
 	if m.body == "" {
@@ -87,6 +139,10 @@ func convertMessageType(incomeType string) Type {
 		return TypeResource
 	case "challenge":
 		return TypeChallenge
+	case "guide":
+		return TypeGuide
+	case "hello":
+		return TypeHello
 	default:
 		return TypeErr
 	}
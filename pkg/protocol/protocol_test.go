@@ -31,6 +31,12 @@ func TestParseFromReader(t *testing.T) {
 			want:    protocol.NewMessage(protocol.TypeChallenge, "test challenge"),
 			wantErr: false,
 		},
+		{
+			name:    "guide",
+			str:     "guide|test tour\n",
+			want:    protocol.NewMessage(protocol.TypeGuide, "test tour"),
+			wantErr: false,
+		},
 		{
 			name:    "invalid type",
 			str:     "invalid|test challenge",
@@ -75,3 +81,21 @@ func TestMessage_ToString(t *testing.T) {
 
 	assert.Equal(t, fmt.Sprintf("%s|%s", protocol.TypeResource, "testBody"), msg.ToString())
 }
+
+func TestMessage_InReplyTo(t *testing.T) {
+	t.Parallel()
+
+	request := protocol.NewMessage(protocol.TypeChallenge, "").WithID("7").WithHeader("trace", "abc")
+	modified := request.WithHeader("trace", "def")
+
+	// headers of copies are independent
+	assert.Equal(t, "abc", request.GetHeader("trace"))
+	assert.Equal(t, "def", modified.GetHeader("trace"))
+
+	reply := protocol.NewMessage(protocol.TypeResource, "quote").InReplyTo(request)
+
+	assert.Equal(t, "7", reply.GetID())
+	assert.Equal(t, map[string]string{"trace": "abc"}, reply.GetHeaders())
+	assert.Equal(t, "quote", reply.GetBody())
+	assert.Equal(t, "resource|quote", reply.ToString())
+}
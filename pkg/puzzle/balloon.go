@@ -0,0 +1,90 @@
+package puzzle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+)
+
+// BalloonName identifies memory-hard Balloon puzzles on the wire.
+const BalloonName = balloon.Prefix
+
+type balloonPuzzle struct{}
+
+func (balloonPuzzle) Name() string {
+	return BalloonName
+}
+
+func (balloonPuzzle) Parse(data string) (Challenge, error) {
+	c, err := balloon.FromString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Params returns space cost, time cost and difficulty bits of the puzzle.
+func (balloonPuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(*balloon.Puzzle)
+	if !ok {
+		return ""
+	}
+
+	return balloonParams(c.GetParams())
+}
+
+func (balloonPuzzle) Solve(ctx context.Context, challenge Challenge, _ Exchange) (Challenge, error) {
+	c, ok := challenge.(*balloon.Puzzle)
+	if !ok {
+		return nil, ErrChallengeType
+	}
+
+	if err := balloon.NewSolver(0).Solve(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type balloonIssuer struct {
+	params balloon.Params
+}
+
+// NewBalloonIssuer returns Issuer of Balloon puzzles with given parameters.
+func NewBalloonIssuer(params balloon.Params) Issuer {
+	return balloonIssuer{
+		params: params,
+	}
+}
+
+func (balloonIssuer) Name() string {
+	return BalloonName
+}
+
+func (i balloonIssuer) Params() string {
+	return balloonParams(i.params)
+}
+
+func (i balloonIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	c, err := balloon.New(resource, i.params, date, balloon.WithRand(rand))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (balloonIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(*balloon.Puzzle)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(c.Validate(limits.dateOptions()...))
+}
+
+func balloonParams(params balloon.Params) string {
+	return fmt.Sprintf("%d:%d:%d", params.SpaceCost, params.TimeCost, params.Bits)
+}
@@ -0,0 +1,48 @@
+package puzzle
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Kinds of errors, common for all puzzles. Errors of Parse function and of Verify and Step methods of issuers
+// match one of them with errors.Is, as well as the error of the puzzle package, so callers can handle errors
+// of any puzzle the same way.
+var (
+	ErrInvalidChallenge = errors.New("malformed challenge")
+	ErrExpired          = errors.New("challenge is expired")
+	ErrInvalidSolution  = errors.New("challenge is not solved")
+)
+
+// Error is an error of puzzle implementation with its kind.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the kind of the error.
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// verifyError returns error of solution verification with its kind: ErrExpired for expired challenge
+// and ErrInvalidSolution for others, e.g. future date or invalid hash.
+func verifyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, challenge.ErrExpired):
+		return &Error{Kind: ErrExpired, Err: err}
+	default:
+		return &Error{Kind: ErrInvalidSolution, Err: err}
+	}
+}
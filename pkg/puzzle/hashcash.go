@@ -0,0 +1,141 @@
+package puzzle
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// HashcashName identifies hashcash stamps on the wire.
+const HashcashName = "hashcash"
+
+// HashcashChallenge is a hashcash stamp, which string representation is prefixed with HashcashName.
+type HashcashChallenge struct {
+	*hashcash.Stamp
+}
+
+// ToString returns string representation of the stamp with puzzle name.
+func (c HashcashChallenge) ToString() string {
+	return HashcashName + ":" + c.Stamp.ToString()
+}
+
+type hashcashPuzzle struct {
+	solverOpts []hashcash.SolverOption
+}
+
+// NewHashcash returns hashcash Puzzle, which solves stamps on all CPUs with given solver options,
+// e.g. to report progress. Register it to replace default hashcash implementation.
+func NewHashcash(opts ...hashcash.SolverOption) Puzzle {
+	return hashcashPuzzle{
+		solverOpts: opts,
+	}
+}
+
+func (hashcashPuzzle) Name() string {
+	return HashcashName
+}
+
+// Parse parses stamp with or without puzzle name.
+func (hashcashPuzzle) Parse(data string) (Challenge, error) {
+	stamp, err := hashcash.FromString(strings.TrimPrefix(data, HashcashName+":"))
+	if err != nil {
+		return nil, err
+	}
+
+	return HashcashChallenge{Stamp: stamp}, nil
+}
+
+// Params returns hash algorithm and difficulty of the stamp, followed by format for classic stamps.
+func (hashcashPuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(HashcashChallenge)
+	if !ok {
+		return ""
+	}
+
+	return hashcashParams(c.Stamp)
+}
+
+func (p hashcashPuzzle) Solve(ctx context.Context, challenge Challenge, _ Exchange) (Challenge, error) {
+	c, ok := challenge.(HashcashChallenge)
+	if !ok {
+		return nil, ErrChallengeType
+	}
+
+	if err := hashcash.NewSolver(0, p.solverOpts...).Solve(ctx, c.Stamp); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type hashcashIssuer struct {
+	bits   int
+	opts   []hashcash.Option
+	params string
+}
+
+// NewHashcashIssuer returns Issuer of hashcash stamps with given difficulty bits and stamp options.
+func NewHashcashIssuer(bits int, opts ...hashcash.Option) Issuer {
+	issuer := hashcashIssuer{
+		bits: bits,
+		opts: opts,
+	}
+
+	// invalid options are reported by Issue
+	if stamp, err := issuer.newStamp("", 0, "params"); err == nil {
+		issuer.params = hashcashParams(stamp)
+	}
+
+	return issuer
+}
+
+func (hashcashIssuer) Name() string {
+	return HashcashName
+}
+
+func (i hashcashIssuer) Params() string {
+	return i.params
+}
+
+func (i hashcashIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	stamp, err := i.newStamp(resource, date, rand)
+	if err != nil {
+		return nil, err
+	}
+
+	return HashcashChallenge{Stamp: stamp}, nil
+}
+
+func (hashcashIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(HashcashChallenge)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(c.Validate(limits.dateOptions()...))
+}
+
+func (i hashcashIssuer) newStamp(resource string, date int64, rand string) (*hashcash.Stamp, error) {
+	opts := i.opts
+	if rand != "" {
+		opts = append(opts[:len(opts):len(opts)], hashcash.WithRand(rand))
+	}
+
+	return hashcash.New(resource, i.bits, date, opts...)
+}
+
+func hashcashParams(stamp *hashcash.Stamp) string {
+	difficulty := strconv.Itoa(stamp.GetBits())
+	if target := stamp.GetTarget(); target != nil {
+		difficulty = "0x" + target.Text(16)
+	}
+
+	params := string(stamp.GetAlgorithm()) + ":" + difficulty
+	if stamp.IsClassic() {
+		params += ":classic"
+	}
+
+	return params
+}
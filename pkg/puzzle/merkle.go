@@ -0,0 +1,90 @@
+package puzzle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+)
+
+// MerkleName identifies Merkle tree puzzles on the wire.
+const MerkleName = merkle.Prefix
+
+type merklePuzzle struct{}
+
+func (merklePuzzle) Name() string {
+	return MerkleName
+}
+
+func (merklePuzzle) Parse(data string) (Challenge, error) {
+	c, err := merkle.FromString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Params returns height of the tree and number of proofs.
+func (merklePuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(*merkle.Puzzle)
+	if !ok {
+		return ""
+	}
+
+	return merkleParams(c.GetParams())
+}
+
+func (merklePuzzle) Solve(ctx context.Context, challenge Challenge, _ Exchange) (Challenge, error) {
+	c, ok := challenge.(*merkle.Puzzle)
+	if !ok {
+		return nil, ErrChallengeType
+	}
+
+	if err := merkle.NewSolver(0).Solve(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type merkleIssuer struct {
+	params merkle.Params
+}
+
+// NewMerkleIssuer returns Issuer of Merkle puzzles with given parameters.
+func NewMerkleIssuer(params merkle.Params) Issuer {
+	return merkleIssuer{
+		params: params,
+	}
+}
+
+func (merkleIssuer) Name() string {
+	return MerkleName
+}
+
+func (i merkleIssuer) Params() string {
+	return merkleParams(i.params)
+}
+
+func (i merkleIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	c, err := merkle.New(resource, i.params, date, merkle.WithRand(rand))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (merkleIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(*merkle.Puzzle)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(c.Validate(limits.dateOptions()...))
+}
+
+func merkleParams(params merkle.Params) string {
+	return fmt.Sprintf("%d:%d", params.Height, params.Proofs)
+}
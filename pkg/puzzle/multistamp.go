@@ -0,0 +1,122 @@
+package puzzle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+)
+
+// MultiStampName identifies hashcash multi-stamps on the wire.
+const MultiStampName = hashcash.MultiPrefix
+
+type multiStampPuzzle struct {
+	solverOpts []hashcash.SolverOption
+}
+
+// NewMultiStamp returns multi-stamp Puzzle, which solves sub-stamps on all CPUs with given solver options,
+// e.g. to report progress of every sub-stamp. Register it to replace default multi-stamp implementation.
+func NewMultiStamp(opts ...hashcash.SolverOption) Puzzle {
+	return multiStampPuzzle{
+		solverOpts: opts,
+	}
+}
+
+func (multiStampPuzzle) Name() string {
+	return MultiStampName
+}
+
+func (multiStampPuzzle) Parse(data string) (Challenge, error) {
+	c, err := hashcash.MultiFromString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Params returns hash algorithm, total difficulty bits and sub-stamps count of the multi-stamp.
+func (multiStampPuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(*hashcash.MultiStamp)
+	if !ok {
+		return ""
+	}
+
+	return multiStampParams(c)
+}
+
+func (p multiStampPuzzle) Solve(ctx context.Context, challenge Challenge, _ Exchange) (Challenge, error) {
+	c, ok := challenge.(*hashcash.MultiStamp)
+	if !ok {
+		return nil, ErrChallengeType
+	}
+
+	if err := hashcash.NewSolver(0, p.solverOpts...).SolveMulti(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type multiStampIssuer struct {
+	bits   int
+	count  int
+	opts   []hashcash.Option
+	params string
+}
+
+// NewMultiStampIssuer returns Issuer of multi-stamps with count sub-stamps, which total expected work
+// equals work of a single hashcash stamp with given difficulty bits. Only WithAlgorithm option is meaningful.
+func NewMultiStampIssuer(bits, count int, opts ...hashcash.Option) Issuer {
+	issuer := multiStampIssuer{
+		bits:  bits,
+		count: count,
+		opts:  opts,
+	}
+
+	// invalid options are reported by Issue
+	if stamp, err := issuer.newStamp("", 0, "params"); err == nil {
+		issuer.params = multiStampParams(stamp)
+	}
+
+	return issuer
+}
+
+func (multiStampIssuer) Name() string {
+	return MultiStampName
+}
+
+func (i multiStampIssuer) Params() string {
+	return i.params
+}
+
+func (i multiStampIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	stamp, err := i.newStamp(resource, date, rand)
+	if err != nil {
+		return nil, err
+	}
+
+	return stamp, nil
+}
+
+func (multiStampIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(*hashcash.MultiStamp)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(c.Validate(limits.dateOptions()...))
+}
+
+func (i multiStampIssuer) newStamp(resource string, date int64, rand string) (*hashcash.MultiStamp, error) {
+	opts := i.opts
+	if rand != "" {
+		opts = append(opts[:len(opts):len(opts)], hashcash.WithRand(rand))
+	}
+
+	return hashcash.NewMulti(resource, i.bits, i.count, date, opts...)
+}
+
+func multiStampParams(stamp *hashcash.MultiStamp) string {
+	return fmt.Sprintf("%s:%d:%d", stamp.GetAlgorithm(), stamp.GetBits(), stamp.GetCount())
+}
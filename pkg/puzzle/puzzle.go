@@ -0,0 +1,76 @@
+package puzzle
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var (
+	ErrUnknownPuzzle   = errors.New("unknown puzzle type")
+	ErrChallengeType   = errors.New("challenge belongs to another puzzle type")
+	ErrInteractiveOnly = errors.New("puzzle can be solved only with exchange of intermediate challenges")
+)
+
+// Challenge is an issued puzzle: not solved challenge is sent by server, and solved one is sent back by client.
+// String representation starts with name of the puzzle followed by ":", so it identifies its puzzle type on the wire.
+type Challenge interface {
+	GetResource() string
+	GetRandValue() string
+	GetDate() int64
+	ToString() string
+}
+
+// Exchange sends intermediate challenge to the server and returns challenge, which server sent back.
+// It's used by interactive puzzles, which can't be solved by client alone.
+type Exchange func(ctx context.Context, challenge Challenge) (Challenge, error)
+
+// Puzzle is a type of client puzzles, registered by its name.
+type Puzzle interface {
+	// Name identifies puzzle type on the wire.
+	Name() string
+	// Parse parses challenge or its solution from string representation.
+	Parse(data string) (Challenge, error)
+	// Params returns canonical representation of the challenge cost parameters,
+	// so challenges of the same difficulty have equal params.
+	Params(challenge Challenge) string
+	// Solve solves the challenge and returns its solution. Interactive puzzles use exchange to talk to the server.
+	Solve(ctx context.Context, challenge Challenge, exchange Exchange) (Challenge, error)
+}
+
+// Issuer issues challenges of one puzzle type with fixed parameters and verifies their solutions on the server.
+type Issuer interface {
+	// Name returns name of the puzzle type of issued challenges.
+	Name() string
+	// Params returns canonical representation of the cost parameters of issued challenges.
+	Params() string
+	// Issue returns new challenge for the resource. Random rand value is generated, when rand is empty.
+	Issue(resource string, date int64, rand string) (Challenge, error)
+	// Verify verifies date of solved challenge within limits, and then its solution.
+	Verify(challenge Challenge, limits Limits) error
+}
+
+// Interactive is implemented by issuers of puzzles, which are solved by exchange of intermediate challenges with server.
+type Interactive interface {
+	// Step moves challenge one step forward on behalf of the server.
+	Step(challenge Challenge) error
+}
+
+// Limits are limits of the challenge date, applied on verification.
+type Limits struct {
+	MaxAge    time.Duration // MaxAge is maximum age of the challenge, zero disables the check.
+	ClockSkew time.Duration // ClockSkew is tolerated difference between future challenge date and current time.
+	Now       func() time.Time
+}
+
+// dateOptions returns checks of the challenge date with the limits. Current time is time.Now, when Now isn't set.
+func (l Limits) dateOptions() []challenge.DateOption {
+	now := l.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return []challenge.DateOption{challenge.WithMaxAge(l.MaxAge), challenge.WithClockSkew(l.ClockSkew), challenge.WithNow(now)}
+}
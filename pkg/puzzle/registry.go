@@ -0,0 +1,88 @@
+package puzzle
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultPuzzle parses challenges without name of registered puzzle, e.g. hashcash stamps of older clients.
+const DefaultPuzzle = HashcashName
+
+var registry = struct {
+	sync.RWMutex
+	puzzles map[string]Puzzle
+}{
+	puzzles: map[string]Puzzle{
+		HashcashName:   NewHashcash(),
+		BalloonName:    balloonPuzzle{},
+		MerkleName:     merklePuzzle{},
+		MultiStampName: NewMultiStamp(),
+		VDFName:        vdfPuzzle{},
+		TourName:       tourPuzzle{},
+	},
+}
+
+// Register makes puzzle available for parsing and solving under its name.
+// Registering the same name twice replaces previous implementation.
+func Register(puzzle Puzzle) {
+	if puzzle == nil || puzzle.Name() == "" || strings.Contains(puzzle.Name(), ":") {
+		panic("puzzle: invalid puzzle registration")
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	registry.puzzles[puzzle.Name()] = puzzle
+}
+
+// Names returns names of all registered puzzles in sorted order.
+func Names() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	names := make([]string, 0, len(registry.puzzles))
+	for name := range registry.puzzles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Lookup returns registered puzzle with given name or ErrUnknownPuzzle.
+func Lookup(name string) (Puzzle, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	puzzle, ok := registry.puzzles[name]
+	if !ok {
+		return nil, ErrUnknownPuzzle
+	}
+
+	return puzzle, nil
+}
+
+// Parse parses challenge by the puzzle, which name starts the data,
+// and returns the challenge with its puzzle. Data without registered name is parsed by DefaultPuzzle.
+func Parse(data string) (Puzzle, Challenge, error) {
+	name := data
+	if i := strings.IndexByte(data, ':'); i >= 0 {
+		name = data[:i]
+	}
+
+	puzzle, err := Lookup(name)
+	if err != nil {
+		if puzzle, err = Lookup(DefaultPuzzle); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	challenge, err := puzzle.Parse(data)
+	if err != nil {
+		return nil, nil, &Error{Kind: ErrInvalidChallenge, Err: err}
+	}
+
+	return puzzle, challenge, nil
+}
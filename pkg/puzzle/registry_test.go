@@ -0,0 +1,196 @@
+package puzzle_test
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/balloon"
+	"github.com/SergeySlonimsky/pow/pkg/guidedtour"
+	"github.com/SergeySlonimsky/pow/pkg/hashcash"
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+	"github.com/SergeySlonimsky/pow/pkg/merkle"
+	"github.com/SergeySlonimsky/pow/pkg/puzzle"
+)
+
+const resource = "172.21.0.4"
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Subset(t, puzzle.Names(), []string{
+		puzzle.BalloonName,
+		puzzle.HashcashName,
+		puzzle.MerkleName,
+		puzzle.MultiStampName,
+		puzzle.TourName,
+		puzzle.VDFName,
+	})
+}
+
+func TestLookup_Unknown(t *testing.T) {
+	t.Parallel()
+
+	_, err := puzzle.Lookup("unknown")
+	assert.ErrorIs(t, err, puzzle.ErrUnknownPuzzle)
+}
+
+func TestIssuer_Solve_Verify(t *testing.T) {
+	t.Parallel()
+
+	modulus, err := base64.StdEncoding.DecodeString("/////////////////////////////////////////0M=")
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		issuer puzzle.Issuer
+	}{
+		{
+			name:   "hashcash",
+			issuer: puzzle.NewHashcashIssuer(8, hashcash.WithAlgorithm(hashcash.SHA512)),
+		},
+		{
+			name:   "multistamp",
+			issuer: puzzle.NewMultiStampIssuer(10, 4, hashcash.WithAlgorithm(hashcash.SHA512)),
+		},
+		{
+			name:   "balloon",
+			issuer: puzzle.NewBalloonIssuer(balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 2}),
+		},
+		{
+			name:   "merkle",
+			issuer: puzzle.NewMerkleIssuer(merkle.Params{Height: 4, Proofs: 2}),
+		},
+		{
+			name:   "vdf",
+			issuer: puzzle.NewVDFIssuer(new(big.Int).SetBytes(modulus), 64),
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := context.Background()
+
+			challenge, err := tt.issuer.Issue(resource, time.Now().Unix(), "")
+			assert.NoError(t, err)
+
+			kind, parsed, err := puzzle.Parse(challenge.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, tt.issuer.Name(), kind.Name())
+			assert.Equal(t, tt.issuer.Params(), kind.Params(parsed))
+			assert.Equal(t, resource, parsed.GetResource())
+
+			solved, err := kind.Solve(ctx, parsed, nil)
+			assert.NoError(t, err)
+
+			_, parsed, err = puzzle.Parse(solved.ToString())
+			assert.NoError(t, err)
+			assert.NoError(t, tt.issuer.Verify(parsed, puzzle.Limits{MaxAge: time.Minute}))
+		})
+	}
+}
+
+func TestParse_Hashcash(t *testing.T) {
+	t.Parallel()
+
+	const stamp = "3:sha256:16:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:28721"
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "with puzzle name",
+			data: puzzle.HashcashName + ":" + stamp,
+		},
+		{
+			name: "without puzzle name",
+			data: stamp,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			kind, parsed, err := puzzle.Parse(tt.data)
+			assert.NoError(t, err)
+			assert.Equal(t, puzzle.HashcashName, kind.Name())
+			assert.Equal(t, "sha256:16", kind.Params(parsed))
+			assert.Equal(t, puzzle.HashcashName+":"+stamp, parsed.ToString())
+		})
+	}
+}
+
+func TestTour_Solve(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	guides, err := guidedtour.GenerateGuides(3)
+	assert.NoError(t, err)
+
+	issuer := puzzle.NewTourIssuer(guides, 4)
+	interactive, ok := issuer.(puzzle.Interactive)
+	assert.True(t, ok)
+
+	challenge, err := issuer.Issue(resource, time.Now().Unix(), "")
+	assert.NoError(t, err)
+
+	kind, parsed, err := puzzle.Parse(challenge.ToString())
+	assert.NoError(t, err)
+
+	_, err = kind.Solve(ctx, parsed, nil)
+	assert.ErrorIs(t, err, puzzle.ErrInteractiveOnly)
+
+	steps := 0
+	solved, err := kind.Solve(ctx, parsed, func(_ context.Context, c puzzle.Challenge) (puzzle.Challenge, error) {
+		steps++
+
+		_, next, err := puzzle.Parse(c.ToString())
+		if err != nil {
+			return nil, err
+		}
+
+		return next, interactive.Step(next)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, steps)
+	assert.NoError(t, issuer.Verify(solved, puzzle.Limits{MaxAge: time.Minute}))
+}
+
+func TestIssuer_Verify_ChallengeType(t *testing.T) {
+	t.Parallel()
+
+	challenge, err := puzzle.NewMerkleIssuer(merkle.Params{Height: 1, Proofs: 1}).Issue(resource, time.Now().Unix(), "")
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, puzzle.NewHashcashIssuer(8).Verify(challenge, puzzle.Limits{}), puzzle.ErrChallengeType)
+}
+
+func TestErrorKinds(t *testing.T) {
+	t.Parallel()
+
+	issuer := puzzle.NewBalloonIssuer(balloon.Params{SpaceCost: 16, TimeCost: 1, Bits: 16})
+
+	_, _, err := puzzle.Parse("balloon:malformed")
+	assert.ErrorIs(t, err, puzzle.ErrInvalidChallenge)
+
+	issued, err := issuer.Issue(resource, time.Now().Add(-time.Hour).Unix(), "")
+	assert.NoError(t, err)
+
+	err = issuer.Verify(issued, puzzle.Limits{MaxAge: time.Minute})
+	assert.ErrorIs(t, err, puzzle.ErrExpired)
+	assert.ErrorIs(t, err, challenge.ErrExpired)
+
+	// not solved challenge of 16 bits difficulty
+	err = issuer.Verify(issued, puzzle.Limits{})
+	assert.ErrorIs(t, err, puzzle.ErrInvalidSolution)
+	assert.ErrorIs(t, err, balloon.ErrInvalidHash)
+}
@@ -0,0 +1,116 @@
+package puzzle
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/SergeySlonimsky/pow/pkg/guidedtour"
+)
+
+// TourName identifies guided tours on the wire.
+const TourName = guidedtour.Prefix
+
+type tourPuzzle struct{}
+
+func (tourPuzzle) Name() string {
+	return TourName
+}
+
+func (tourPuzzle) Parse(data string) (Challenge, error) {
+	c, err := guidedtour.FromString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Params returns length of the tour and number of guides.
+func (tourPuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(*guidedtour.Tour)
+	if !ok {
+		return ""
+	}
+
+	return tourParams(c.GetLength(), c.GetGuides())
+}
+
+// Solve visits all guides of the tour in sequence, sending the tour to the server with exchange.
+func (tourPuzzle) Solve(ctx context.Context, challenge Challenge, exchange Exchange) (Challenge, error) {
+	if exchange == nil {
+		return nil, ErrInteractiveOnly
+	}
+
+	for {
+		tour, ok := challenge.(*guidedtour.Tour)
+		if !ok {
+			return nil, ErrChallengeType
+		}
+
+		if tour.Completed() {
+			return tour, nil
+		}
+
+		var err error
+		if challenge, err = exchange(ctx, tour); err != nil {
+			return nil, err
+		}
+	}
+}
+
+type tourIssuer struct {
+	guides *guidedtour.Guides
+	length int
+}
+
+// NewTourIssuer returns Issuer of guided tours of given length, served by the guides.
+func NewTourIssuer(guides *guidedtour.Guides, length int) Issuer {
+	return tourIssuer{
+		guides: guides,
+		length: length,
+	}
+}
+
+func (tourIssuer) Name() string {
+	return TourName
+}
+
+func (i tourIssuer) Params() string {
+	return tourParams(i.length, i.guides.Count())
+}
+
+func (i tourIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	c, err := guidedtour.New(resource, i.length, i.guides.Count(), date, guidedtour.WithRand(rand))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (i tourIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(*guidedtour.Tour)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(i.guides.Validate(c, limits.dateOptions()...))
+}
+
+// Step makes the next guide of the tour visited.
+func (i tourIssuer) Step(challenge Challenge) error {
+	c, ok := challenge.(*guidedtour.Tour)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	if err := i.guides.Visit(c); err != nil {
+		return &Error{Kind: ErrInvalidSolution, Err: err}
+	}
+
+	return nil
+}
+
+func tourParams(length, guides int) string {
+	return fmt.Sprintf("%d:%d", length, guides)
+}
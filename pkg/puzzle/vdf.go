@@ -0,0 +1,95 @@
+package puzzle
+
+import (
+	"context"
+	"encoding/base64"
+	"math/big"
+	"strconv"
+
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
+)
+
+// VDFName identifies sequential VDF puzzles on the wire.
+const VDFName = vdf.Prefix
+
+type vdfPuzzle struct{}
+
+func (vdfPuzzle) Name() string {
+	return VDFName
+}
+
+func (vdfPuzzle) Parse(data string) (Challenge, error) {
+	c, err := vdf.FromString(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Params returns number of squarings and modulus of the group, so client can't choose another group.
+func (vdfPuzzle) Params(challenge Challenge) string {
+	c, ok := challenge.(*vdf.Puzzle)
+	if !ok {
+		return ""
+	}
+
+	return vdfParams(c.GetModulus(), c.GetIterations())
+}
+
+func (vdfPuzzle) Solve(ctx context.Context, challenge Challenge, _ Exchange) (Challenge, error) {
+	c, ok := challenge.(*vdf.Puzzle)
+	if !ok {
+		return nil, ErrChallengeType
+	}
+
+	if err := vdf.Solve(ctx, c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+type vdfIssuer struct {
+	modulus    *big.Int
+	iterations int
+}
+
+// NewVDFIssuer returns Issuer of VDF puzzles with given number of squarings in RSA group of the modulus.
+// Factorization of the modulus must be unknown to clients, see vdf.GenerateModulus.
+func NewVDFIssuer(modulus *big.Int, iterations int) Issuer {
+	return vdfIssuer{
+		modulus:    new(big.Int).Set(modulus),
+		iterations: iterations,
+	}
+}
+
+func (vdfIssuer) Name() string {
+	return VDFName
+}
+
+func (i vdfIssuer) Params() string {
+	return vdfParams(i.modulus, i.iterations)
+}
+
+func (i vdfIssuer) Issue(resource string, date int64, rand string) (Challenge, error) {
+	c, err := vdf.New(resource, i.modulus, i.iterations, date, vdf.WithRand(rand))
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (vdfIssuer) Verify(challenge Challenge, limits Limits) error {
+	c, ok := challenge.(*vdf.Puzzle)
+	if !ok {
+		return ErrChallengeType
+	}
+
+	return verifyError(c.Validate(limits.dateOptions()...))
+}
+
+func vdfParams(modulus *big.Int, iterations int) string {
+	return strconv.Itoa(iterations) + ":" + base64.StdEncoding.EncodeToString(modulus.Bytes())
+}
@@ -0,0 +1,270 @@
+package vdf
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+// Prefix starts string representation of every puzzle, so it can't be confused with other puzzles.
+const Prefix = "vdf"
+
+// Version is the current puzzle format version.
+const Version = 1
+
+// Limits of puzzle parameters, which protect client from endless puzzles and verifier from large numbers.
+const (
+	MinModulusBits = 256
+	MaxModulusBits = 4096
+	MaxIterations  = 1 << 26
+)
+
+// Defaults need about a second of sequential squarings on a modern CPU core.
+const (
+	DefaultModulusBits = 2048
+	DefaultIterations  = 1 << 17
+)
+
+// MaxPuzzleSize is maximum length of string representation of the puzzle. It fits base64 modulus, output and proof
+// of the largest group and other fields of allowed size, e.g. resource up to challenge.MaxResourceSize bytes,
+// which may be percent-encoded.
+const MaxPuzzleSize = 1024 + 3*((MaxModulusBits/8+2)/3*4)
+
+// puzzleParts is the number of fields of string representation of the puzzle.
+const puzzleParts = 9
+
+var (
+	ErrInvalidParams   = errors.New("invalid puzzle parameters")
+	ErrInvalidFormat   = challenge.ErrInvalidFormat
+	ErrInvalidResource = challenge.ErrInvalidResource
+	ErrInvalidRand     = challenge.ErrInvalidRand
+)
+
+// Puzzle is a verifiable delay function puzzle: client computes y = x^(2^Iterations) in RSA group
+// by sequential squarings, which can't be sped up by parallel hardware,
+// and proves the result with Wesolowski proof, which verifier checks with two short exponentiations.
+type Puzzle struct {
+	modulus    *big.Int
+	iterations int
+	date       int64
+	resource   string
+	rand       string
+	output     *big.Int
+	proof      *big.Int
+}
+
+// Option configures Puzzle created by New.
+type Option func(p *Puzzle)
+
+// WithRand sets rand field of the puzzle instead of generated random value,
+// e.g. to embed data, which allows issuer to authenticate the puzzle. Rand must be base64 string up to challenge.MaxRandSize.
+func WithRand(rand string) Option {
+	return func(p *Puzzle) {
+		p.rand = rand
+	}
+}
+
+// New returns not solved Puzzle in group of the modulus with given resource and number of squarings.
+// Returns error when parameters are out of limits, resource exceeds challenge.MaxResourceSize, rand is invalid
+// or can't generate random base64 string.
+func New(resource string, modulus *big.Int, iterations int, date int64, opts ...Option) (*Puzzle, error) {
+	if err := ValidateParams(modulus, iterations); err != nil {
+		return nil, err
+	}
+
+	if err := challenge.ValidateResource(resource); err != nil {
+		return nil, err
+	}
+
+	puzzle := &Puzzle{
+		modulus:    new(big.Int).Set(modulus),
+		iterations: iterations,
+		date:       date,
+		resource:   resource,
+	}
+
+	for _, opt := range opts {
+		opt(puzzle)
+	}
+
+	var err error
+	if puzzle.rand, err = challenge.Rand(puzzle.rand); err != nil {
+		return nil, err
+	}
+
+	return puzzle, nil
+}
+
+// IsPuzzle reports whether data looks like string representation of VDF puzzle.
+func IsPuzzle(data string) bool {
+	return strings.HasPrefix(data, Prefix+":")
+}
+
+// FromString parses data string to Puzzle.
+// String should be formatted as "vdf:version:iterations:date:resource:rand:modulus:output:proof",
+// where resource is escaped by challenge.EscapeResource, modulus, output and proof are base64 encoded big-endian numbers,
+// output and proof are empty for not solved puzzle. Every field is checked strictly against its format and size limits,
+// so verifier never hashes or exponentiates oversized values.
+func FromString(data string) (*Puzzle, error) {
+	p := challenge.NewParser(data, Prefix, Version, puzzleParts, MaxPuzzleSize)
+
+	puzzle := &Puzzle{
+		iterations: p.Int("iterations"),
+		date:       p.Date(),
+		resource:   p.Resource(),
+		rand:       p.Rand(),
+	}
+
+	modulus, output, proof := p.Field(), p.Field(), p.Field()
+
+	if err := p.Err(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if puzzle.modulus, err = decodeInt(modulus); err != nil {
+		return nil, ErrInvalidParams
+	}
+
+	if err := ValidateParams(puzzle.modulus, puzzle.iterations); err != nil {
+		return nil, err
+	}
+
+	if output == "" && proof == "" {
+		return puzzle, nil
+	}
+
+	if puzzle.output, err = decodeInt(output); err != nil || !puzzle.inGroup(puzzle.output) {
+		return nil, &challenge.FieldError{Field: "output", Err: ErrInvalidFormat}
+	}
+
+	if puzzle.proof, err = decodeInt(proof); err != nil || !puzzle.inGroup(puzzle.proof) {
+		return nil, &challenge.FieldError{Field: "proof", Err: ErrInvalidFormat}
+	}
+
+	return puzzle, nil
+}
+
+// Solve computes output of the puzzle and its proof. It takes Iterations sequential squarings for the output
+// and about the same for the proof. Squaring stops with ctx.Err(), once ctx is done, and output isn't set then.
+func Solve(ctx context.Context, puzzle *Puzzle) error {
+	x := puzzle.input()
+
+	output, err := evaluate(ctx, x, puzzle.modulus, puzzle.iterations)
+	if err != nil {
+		return err
+	}
+
+	l := hashToPrime(puzzle.seed(), x, output)
+	puzzle.proof = prove(x, puzzle.modulus, l, puzzle.iterations)
+	puzzle.output = output
+
+	return nil
+}
+
+// ToString returns string representation of the puzzle, separated with ":".
+func (p *Puzzle) ToString() string {
+	return p.header() + ":" + encodeInt(p.output) + ":" + encodeInt(p.proof)
+}
+
+// Solved reports whether puzzle contains output and proof.
+func (p *Puzzle) Solved() bool {
+	return p.output != nil && p.proof != nil
+}
+
+// Verify verifies output of the puzzle by its Wesolowski proof. Cost doesn't depend on the number of iterations.
+func (p *Puzzle) Verify() bool {
+	if !p.Solved() {
+		return false
+	}
+
+	x := p.input()
+	l := hashToPrime(p.seed(), x, p.output)
+
+	return check(x, p.output, p.proof, p.modulus, l, p.iterations)
+}
+
+// GetModulus returns a copy of RSA modulus of the group.
+func (p *Puzzle) GetModulus() *big.Int {
+	return new(big.Int).Set(p.modulus)
+}
+
+func (p *Puzzle) GetIterations() int {
+	return p.iterations
+}
+
+func (p *Puzzle) GetDate() int64 {
+	return p.date
+}
+
+func (p *Puzzle) GetResource() string {
+	return p.resource
+}
+
+func (p *Puzzle) GetRandValue() string {
+	return p.rand
+}
+
+// header returns string representation of not solved puzzle without trailing separators.
+func (p *Puzzle) header() string {
+	return fmt.Sprintf(
+		"%s:%d:%d:%d:%s:%s:%s",
+		Prefix, Version, p.iterations, p.date, challenge.EscapeResource(p.resource), p.rand, encodeInt(p.modulus),
+	)
+}
+
+// seed returns hash of the challenge, which binds proof to all puzzle parameters.
+func (p *Puzzle) seed() []byte {
+	seed := sha256.Sum256([]byte(p.header()))
+
+	return seed[:]
+}
+
+// input returns group element, which client raises to the power of 2^Iterations.
+func (p *Puzzle) input() *big.Int {
+	return hashToGroup([]byte(p.header()), p.modulus)
+}
+
+func (p *Puzzle) inGroup(value *big.Int) bool {
+	return value.Sign() > 0 && value.Cmp(p.modulus) < 0
+}
+
+// ValidateParams checks, that modulus is odd and its size and the number of squarings are within limits,
+// e.g. to validate configuration of the issuer before any puzzle is created. Returns ErrInvalidParams otherwise.
+func ValidateParams(modulus *big.Int, iterations int) error {
+	if modulus == nil || modulus.BitLen() < MinModulusBits || modulus.BitLen() > MaxModulusBits || modulus.Bit(0) == 0 ||
+		iterations < 1 || iterations > MaxIterations {
+		return ErrInvalidParams
+	}
+
+	return nil
+}
+
+func encodeInt(value *big.Int) string {
+	if value == nil {
+		return ""
+	}
+
+	return base64.StdEncoding.EncodeToString(value.Bytes())
+}
+
+// decodeInt returns ErrInvalidFormat, when data isn't base64 encoded number up to MaxModulusBits.
+func decodeInt(data string) (*big.Int, error) {
+	// size is checked before decoding, so large numbers are rejected cheaply
+	if len(data) > base64.StdEncoding.EncodedLen(MaxModulusBits/8) {
+		return nil, ErrInvalidFormat
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(data)
+	if err != nil {
+		return nil, ErrInvalidFormat
+	}
+
+	return new(big.Int).SetBytes(raw), nil
+}
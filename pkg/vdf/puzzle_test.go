@@ -0,0 +1,303 @@
+package vdf_test
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+	"github.com/SergeySlonimsky/pow/pkg/vdf"
+)
+
+func TestPuzzle_Solve_Verify(t *testing.T) {
+	t.Parallel()
+
+	modulus, err := vdf.GenerateModulus(512)
+	assert.NoError(t, err)
+	assert.Equal(t, 512, modulus.BitLen())
+
+	tests := []struct {
+		name       string
+		iterations int
+	}{
+		{
+			name:       "single squaring",
+			iterations: 1,
+		},
+		{
+			name:       "many squarings",
+			iterations: 1 << 12,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := vdf.New("172.21.0.4", modulus, tt.iterations, time.Now().Unix())
+			assert.NoError(t, err)
+			assert.False(t, puzzle.Verify())
+
+			assert.NoError(t, vdf.Solve(context.Background(), puzzle))
+			assert.True(t, puzzle.Solved())
+			assert.True(t, puzzle.Verify())
+
+			parsed, err := vdf.FromString(puzzle.ToString())
+			assert.NoError(t, err)
+			assert.Equal(t, puzzle.ToString(), parsed.ToString())
+			assert.Equal(t, 0, modulus.Cmp(parsed.GetModulus()))
+			assert.NoError(t, parsed.Validate(challenge.WithMaxAge(time.Minute), challenge.WithClockSkew(0)))
+		})
+	}
+}
+
+func TestPuzzle_Verify_Tampered(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	modulus, err := vdf.GenerateModulus(256)
+	assert.NoError(t, err)
+
+	puzzle, err := vdf.New("172.21.0.4", modulus, 1<<8, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, vdf.Solve(ctx, puzzle))
+
+	fewer, err := vdf.New("172.21.0.4", modulus, 1<<7, puzzle.GetDate(), vdf.WithRand(puzzle.GetRandValue()))
+	assert.NoError(t, err)
+	assert.NoError(t, vdf.Solve(ctx, fewer))
+
+	parts := strings.Split(puzzle.ToString(), ":")
+	fewerParts := strings.Split(fewer.ToString(), ":")
+
+	// output and proof of fewer squarings don't satisfy the puzzle
+	tampered, err := vdf.FromString(strings.Join(append(parts[:7:7], fewerParts[7:]...), ":"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, tampered.Validate(), vdf.ErrInvalidProof)
+
+	// proof of the puzzle doesn't match another output
+	tampered, err = vdf.FromString(strings.Join(append(parts[:7:7], fewerParts[7], parts[8]), ":"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, tampered.Validate(), vdf.ErrInvalidProof)
+}
+
+func TestSolve_Canceled(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	modulus, err := vdf.GenerateModulus(256)
+	assert.NoError(t, err)
+
+	puzzle, err := vdf.New("172.21.0.4", modulus, vdf.MaxIterations, time.Now().Unix())
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, vdf.Solve(ctx, puzzle), context.Canceled)
+	assert.False(t, puzzle.Solved())
+}
+
+func TestNew_InvalidParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		modulus    *big.Int
+		iterations int
+	}{
+		{
+			name:       "no modulus",
+			modulus:    nil,
+			iterations: 1,
+		},
+		{
+			name:       "zero modulus",
+			modulus:    big.NewInt(0),
+			iterations: 1,
+		},
+		{
+			name:       "modulus of one",
+			modulus:    big.NewInt(1),
+			iterations: 1,
+		},
+		{
+			name:       "small modulus",
+			modulus:    big.NewInt(3233),
+			iterations: 1,
+		},
+		{
+			name:       "even modulus",
+			modulus:    new(big.Int).Lsh(big.NewInt(1), 512),
+			iterations: 1,
+		},
+		{
+			name:       "no iterations",
+			modulus:    new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 512), big.NewInt(1)),
+			iterations: 0,
+		},
+		{
+			name:       "too many iterations",
+			modulus:    new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 512), big.NewInt(1)),
+			iterations: vdf.MaxIterations + 1,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.ErrorIs(t, vdf.ValidateParams(tt.modulus, tt.iterations), vdf.ErrInvalidParams)
+
+			_, err := vdf.New("172.21.0.4", tt.modulus, tt.iterations, time.Now().Unix())
+			assert.ErrorIs(t, err, vdf.ErrInvalidParams)
+		})
+	}
+}
+
+func TestNew_IPv6(t *testing.T) {
+	t.Parallel()
+
+	modulus, err := vdf.GenerateModulus(512)
+	assert.NoError(t, err)
+
+	puzzle, err := vdf.New("::1", modulus, 16, time.Now().Unix())
+	assert.NoError(t, err)
+	assert.NoError(t, vdf.Solve(context.Background(), puzzle))
+
+	parsed, err := vdf.FromString(puzzle.ToString())
+	assert.NoError(t, err)
+	assert.Equal(t, "::1", parsed.GetResource())
+	assert.True(t, parsed.Verify())
+
+	_, err = vdf.New("::1", modulus, 16, time.Now().Unix(), vdf.WithRand("a:b"))
+	assert.ErrorIs(t, err, vdf.ErrInvalidRand)
+}
+
+func TestFromString(t *testing.T) {
+	t.Parallel()
+
+	// 2^256 - 189 is an odd number of 256 bits
+	modulus := "/////////////////////////////////////////0M="
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+	}{
+		{
+			name: "challenge",
+			data: "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+		},
+		{
+			name: "solution",
+			data: "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + ":Ag==:Aw==",
+		},
+		{
+			name: "escaped IPv6 resource",
+			data: "vdf:1:1024:1656370862:%3A%3A1:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+		},
+		{
+			name:    "not escaped IPv6 resource",
+			data:    "vdf:1:1024:1656370862:::1:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+			wantErr: true,
+		},
+		{
+			name:    "resource exceeds limit",
+			data:    "vdf:1:1024:1656370862:" + strings.Repeat("r", 257) + ":FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+			wantErr: true,
+		},
+		{
+			name:    "rand exceeds limit",
+			data:    "vdf:1:1024:1656370862:172.21.0.4:" + strings.Repeat("r", 132) + ":" + modulus + "::",
+			wantErr: true,
+		},
+		{
+			name:    "puzzle exceeds limit",
+			data:    "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::" + strings.Repeat("A", vdf.MaxPuzzleSize),
+			wantErr: true,
+		},
+		{
+			name:    "not canonical iterations",
+			data:    "vdf:1:01024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+			wantErr: true,
+		},
+		{
+			name:    "output out of group",
+			data:    "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + ":" + modulus + ":Aw==",
+			wantErr: true,
+		},
+		{
+			name:    "missing proof",
+			data:    "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + ":Ag==:",
+			wantErr: true,
+		},
+		{
+			name:    "small modulus",
+			data:    "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:DKE=::",
+			wantErr: true,
+		},
+		{
+			name:    "unknown version",
+			data:    "vdf:2:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus + "::",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			puzzle, err := vdf.FromString(tt.data)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.data, puzzle.ToString())
+			}
+		})
+	}
+}
+
+func TestFromString_InvalidNumbers(t *testing.T) {
+	t.Parallel()
+
+	// 2^256 - 189 is an odd number of 256 bits
+	modulus := "/////////////////////////////////////////0M="
+	header := "vdf:1:1024:1656370862:172.21.0.4:FrZUho0yFjtWiiMonJTt55OFQ9k=:" + modulus
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{
+			name: "output out of group",
+			data: header + ":" + modulus + ":Aw==",
+		},
+		{
+			name: "output exceeds limit",
+			data: header + ":" + strings.Repeat("A", 1024) + ":Aw==",
+		},
+		{
+			name: "proof is not base64",
+			data: header + ":Ag==:proof?",
+		},
+		{
+			name: "missing proof",
+			data: header + ":Ag==:",
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := vdf.FromString(tt.data)
+			assert.ErrorIs(t, err, vdf.ErrInvalidFormat)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package vdf
+
+import (
+	"errors"
+
+	"github.com/SergeySlonimsky/pow/pkg/internal/challenge"
+)
+
+var ErrInvalidProof = errors.New("puzzle output is missing or its proof is invalid")
+
+// Validate checks puzzle date with the options before the costlier proof verification.
+// Returns date error of the options or ErrInvalidProof.
+func (p *Puzzle) Validate(opts ...challenge.DateOption) error {
+	if err := challenge.ValidateDate(p.date, opts...); err != nil {
+		return err
+	}
+
+	if !p.Verify() {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
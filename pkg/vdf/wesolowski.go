@@ -0,0 +1,135 @@
+package vdf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"io"
+	"math/big"
+)
+
+// Challenge prime of the proof has primeBits and is tested with primeRounds Miller-Rabin rounds.
+const (
+	primeBits   = 128
+	primeRounds = 20
+)
+
+// checkInterval is a number of squarings between context checks.
+const checkInterval = 1 << 10
+
+// Domain separation tags of hashed data.
+const (
+	inputTag = 0
+	primeTag = 1
+)
+
+var one = big.NewInt(1)
+
+// GenerateModulus returns RSA modulus of given size, which factorization is discarded,
+// so nobody can shortcut repeated squaring by computing the group order.
+func GenerateModulus(bits int) (*big.Int, error) {
+	if bits < MinModulusBits || bits > MaxModulusBits {
+		return nil, ErrInvalidParams
+	}
+
+	for {
+		p, err := rand.Prime(rand.Reader, bits/2)
+		if err != nil {
+			return nil, err
+		}
+
+		q, err := rand.Prime(rand.Reader, bits-bits/2)
+		if err != nil {
+			return nil, err
+		}
+
+		modulus := new(big.Int).Mul(p, q)
+		if p.Cmp(q) != 0 && modulus.BitLen() == bits {
+			return modulus, nil
+		}
+	}
+}
+
+// hashToGroup maps data to an element of the group.
+func hashToGroup(data []byte, modulus *big.Int) *big.Int {
+	sum := sha512.Sum512(append([]byte{inputTag}, data...))
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(sum[:]), modulus)
+}
+
+// hashToPrime returns challenge prime of Wesolowski proof, derived from all public values,
+// so prover can't choose it after the output is computed.
+func hashToPrime(seed []byte, x, y *big.Int) *big.Int {
+	var counter [8]byte
+
+	prime := new(big.Int)
+
+	for i := uint64(0); ; i++ {
+		binary.BigEndian.PutUint64(counter[:], i)
+
+		h := sha256.New()
+		h.Write([]byte{primeTag})
+		h.Write(seed)
+		writeInt(h, x)
+		writeInt(h, y)
+		h.Write(counter[:])
+
+		prime.SetBytes(h.Sum(nil)[:primeBits/8])
+
+		if prime.ProbablyPrime(primeRounds) {
+			return prime
+		}
+	}
+}
+
+// evaluate computes x^(2^iterations) by sequential squaring, checking context every checkInterval squarings.
+func evaluate(ctx context.Context, x, modulus *big.Int, iterations int) (*big.Int, error) {
+	y := new(big.Int).Set(x)
+	square := new(big.Int)
+
+	for i := 0; i < iterations; i++ {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+
+		square.Mul(y, y)
+		y.Mod(square, modulus)
+	}
+
+	return y, nil
+}
+
+// prove computes Wesolowski proof x^floor(2^iterations / l).
+func prove(x, modulus, l *big.Int, iterations int) *big.Int {
+	exponent := new(big.Int).Lsh(one, uint(iterations))
+	exponent.Div(exponent, l)
+
+	return new(big.Int).Exp(x, exponent, modulus)
+}
+
+// check verifies Wesolowski proof: proof^l * x^r == y, where r = 2^iterations mod l.
+// It costs two exponentiations with primeBits exponents regardless of iterations.
+func check(x, y, proof, modulus, l *big.Int, iterations int) bool {
+	r := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(iterations)), l)
+
+	left := new(big.Int).Exp(proof, l, modulus)
+	left.Mul(left, new(big.Int).Exp(x, r, modulus))
+	left.Mod(left, modulus)
+
+	return left.Cmp(y) == 0
+}
+
+// writeInt writes length prefixed big-endian bytes of the value.
+func writeInt(w io.Writer, value *big.Int) {
+	var size [4]byte
+
+	data := value.Bytes()
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+
+	_, _ = w.Write(size[:])
+	_, _ = w.Write(data)
+}